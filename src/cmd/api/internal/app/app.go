@@ -2,18 +2,23 @@ package app
 
 import (
 	"context"
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	stderrors "errors"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/pkg/errors"
 	echoSwagger "github.com/swaggo/echo-swagger"
+	"net/http"
+	"os"
+	"os/signal"
 	"song-library-api/src/cmd/api/internal/config"
 	middleware2 "song-library-api/src/cmd/api/internal/server/http/middleware"
 	"song-library-api/src/cmd/api/internal/server/http/route"
 	v1 "song-library-api/src/cmd/api/internal/server/http/v1"
+	v2 "song-library-api/src/cmd/api/internal/server/http/v2"
 	"song-library-api/src/cmd/api/internal/server/http/validator"
+	"song-library-api/src/cmd/api/internal/tracing"
+	"syscall"
+	"time"
 )
 
 type App struct {
@@ -21,89 +26,191 @@ type App struct {
 	httpServer *echo.Echo
 }
 
-func New() (*App, error) {
-	a := &App{}
-	ctx := context.Background()
-	if err := a.initDeps(ctx); err != nil {
-		return nil, err
+// New loads the configuration from the given file and profile, both may be
+// empty, and wires the dependencies of the application.
+func New(configFile, profile string) (*App, error) {
+	cfg, err := config.Load(configFile, profile)
+	if err != nil {
+		return nil, errors.Wrap(err, "load config")
 	}
-	return a, nil
-}
 
-func (a *App) Run() error {
-	defer a.provider.Logger().Info("Application started")
-	return a.runHttpServer()
+	return &App{provider: NewServiceProvider(cfg)}, nil
 }
 
-func (a *App) initDeps(ctx context.Context) error {
-	inits := []func(context.Context) error{
-		a.initConfig,
-		a.initServiceProvider,
-		a.initHttpServer,
-		a.applyMigration,
+// Run applies migrations and serves HTTP until ctx is cancelled or the
+// process receives SIGINT or SIGTERM. The worker runs alongside the server
+// when it is enabled. On shutdown in-flight requests are drained first, then
+// the worker is stopped, both within the configured shutdown timeout.
+func (a *App) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	a.provider.TracerProvider()
+	if err := a.applyMigration(ctx); err != nil {
+		return err
+	}
+	if err := a.initHttpServer(ctx); err != nil {
+		return err
 	}
 
-	for _, f := range inits {
-		err := f(ctx)
-		if err != nil {
-			return err
-		}
+	// The worker is stopped explicitly after the server, not by the signal.
+	workerCtx, stopWorker := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopWorker()
+	workerDone := make(chan struct{})
+	if a.provider.Config().WorkerEnabled {
+		go func() {
+			defer close(workerDone)
+			_ = a.runWorker(workerCtx)
+		}()
+	} else {
+		a.provider.Logger().Warn("Worker disabled, enqueued jobs wait for a worker of another instance")
+		close(workerDone)
 	}
 
-	return nil
-}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- a.runHttpServer()
+	}()
 
-func (a *App) applyMigration(_ context.Context) error {
-	m, err := migrate.New("file://./src/cmd/api/migrations/postgresql", a.provider.Config().PostgresConn)
-	if err != nil {
-		return err
+	a.provider.Logger().Info("Application started", "address", a.provider.Config().ServerAddress)
+
+	var err error
+	select {
+	case <-ctx.Done():
+		a.provider.Logger().Info("Shutting down", "timeout", a.provider.Config().ShutdownTimeout)
+	case err = <-serverErr:
 	}
 
-	if err = m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return err
+	return stderrors.Join(err, a.shutdown(stopWorker, workerDone))
+}
+
+func (a *App) shutdown(stopWorker context.CancelFunc, workerDone <-chan struct{}) error {
+	a.provider.HealthChecker().SetShuttingDown()
+	time.Sleep(a.provider.Config().ShutdownDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.provider.Config().ShutdownTimeout)
+	defer cancel()
+
+	var err error
+	if shutdownErr := a.httpServer.Shutdown(ctx); shutdownErr != nil {
+		err = errors.Wrap(shutdownErr, "failed to shut down http server")
 	}
 
-	version, dirty, err := m.Version()
-	if err != nil {
-		return err
+	stopWorker()
+	select {
+	case <-workerDone:
+	case <-ctx.Done():
+		err = stderrors.Join(err, errors.New("worker did not stop before the shutdown timeout"))
 	}
 
-	a.provider.Logger().Info("Applied migration", "version", version, "dirty", dirty)
+	a.provider.Logger().Info("Application stopped")
 
-	return nil
+	return err
 }
 
-func (a *App) initConfig(_ context.Context) error {
-	return config.Load()
+// Close releases the resources of the service provider.
+func (a *App) Close() error {
+	return a.provider.Close()
 }
 
-func (a *App) initServiceProvider(_ context.Context) error {
-	a.provider = NewServiceProvider()
-	return nil
+// RunWorker processes background jobs without serving HTTP until ctx is cancelled.
+func (a *App) RunWorker(ctx context.Context) error {
+	a.provider.TracerProvider()
+	if err := a.applyMigration(ctx); err != nil {
+		return err
+	}
+
+	return a.runWorker(ctx)
 }
 
 func (a *App) initHttpServer(_ context.Context) error {
+	cfg := a.provider.Config()
+
 	a.httpServer = echo.New()
+	a.httpServer.Server.ReadTimeout = cfg.ServerReadTimeout
+	a.httpServer.Server.ReadHeaderTimeout = cfg.ServerReadHeaderTimeout
+	a.httpServer.Server.WriteTimeout = cfg.ServerWriteTimeout
+	a.httpServer.Server.IdleTimeout = cfg.ServerIdleTimeout
+	a.httpServer.IPExtractor = echo.ExtractIPDirect()
+	if cfg.TrustProxyHeaders {
+		a.httpServer.IPExtractor = echo.ExtractIPFromXFFHeader()
+	}
 
+	a.httpServer.Use(a.provider.Metrics().Middleware(isProbe))
+	a.httpServer.Use(tracing.Middleware(isProbe))
+	a.httpServer.Use(middleware2.RequestIDMiddleware)
+	// Probes and scrapes would drown the access log.
+	a.httpServer.Use(middleware2.LoggerMiddleware(a.provider.Logger(), isProbe))
 	a.httpServer.Use(middleware.Recover())
-	a.httpServer.Use(middleware.Logger())
+	if len(cfg.CORSAllowedOrigins) > 0 {
+		a.httpServer.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+			AllowOrigins:     cfg.CORSAllowedOrigins,
+			AllowHeaders:     cfg.CORSAllowedHeaders,
+			AllowCredentials: cfg.CORSAllowCredentials,
+			ExposeHeaders: []string{
+				echo.HeaderXRequestID,
+				middleware2.HeaderRateLimitLimit,
+				middleware2.HeaderRateLimitRemaining,
+				middleware2.HeaderRateLimitReset,
+				middleware2.HeaderRateLimitPolicy,
+				echo.HeaderRetryAfter,
+			},
+			MaxAge: cfg.CORSMaxAge,
+		}))
+	}
+	a.httpServer.Use(middleware.BodyLimit(cfg.ServerBodyLimit))
 	a.httpServer.Use(middleware2.ErrorHandlerMiddleware)
 
 	a.httpServer.Validator = validator.NewRequestValidator()
 
-	group := a.httpServer.Group("")
-	route.InitSongRoutes(group, v1.NewSongController(
+	limiter := middleware2.NewRateLimiter(a.provider.RateLimiter(), a.provider.Logger())
+	// The key is checked within the versions, so that a rejected key gets
+	// the error body of the version. Attempts are limited per IP first.
+	authLimit := limiter.LimitAuth()
+	apiKey := middleware2.APIKeyMiddleware(a.provider.APIKeyService().Authenticate)
+	timeouts := route.Timeouts{Default: cfg.ServerRequestTimeout, Bulk: cfg.ServerBulkRequestTimeout}
+
+	route.InitHealthRoutes(a.httpServer.Group(""), v1.NewHealthController(a.provider.HealthChecker()))
+
+	// v1 is frozen for existing clients, changes to the contract go into v2.
+	route.InitSongRoutes(a.httpServer.Group("", authLimit, apiKey), v1.NewSongController(
 		a.provider.SongService(),
-		a.provider.GroupService()))
+		a.provider.GroupService()),
+		limiter, timeouts)
+	route.InitSongRoutes(a.httpServer.Group("/v2", middleware2.ErrorEnvelopeMiddleware, authLimit, apiKey), v2.NewSongController(
+		a.provider.SongService(),
+		a.provider.GroupService()),
+		limiter, timeouts)
 
 	a.httpServer.GET("/swagger/*", echoSwagger.WrapHandler)
+	a.httpServer.GET("/v2/swagger/*", echoSwagger.EchoWrapHandler(echoSwagger.InstanceName("v2")))
+	// Metrics expose the traffic of every client, only key holders may scrape them.
+	a.httpServer.GET("/metrics", echo.WrapHandler(a.provider.Metrics().Handler()),
+		authLimit, apiKey, middleware2.RequireAPIKeyMiddleware)
 
 	return nil
 }
 
+func isProbe(c echo.Context) bool {
+	switch c.Path() {
+	case "/healthz", "/readyz", "/metrics":
+		return true
+	default:
+		return false
+	}
+}
+
+func (a *App) runWorker(ctx context.Context) error {
+	if err := a.provider.Worker().Run(ctx); err != nil {
+		a.provider.Logger().Error("worker failed", "error", err)
+		return errors.Wrap(err, "worker failed")
+	}
+	return nil
+}
+
 func (a *App) runHttpServer() error {
 	err := a.httpServer.Start(a.provider.Config().ServerAddress)
-	if err != nil {
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return errors.Wrap(err, "failed to start http server")
 	}
 	return nil
@@ -0,0 +1,389 @@
+package app
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	"io"
+	"os"
+	"song-library-api/src/cmd/api/internal/converter"
+	"song-library-api/src/cmd/api/internal/importer"
+	"song-library-api/src/cmd/api/internal/model"
+	"strconv"
+	"strings"
+)
+
+// NewCLI builds the command line interface. Every command creates its own
+// App, so they share the serviceProvider wiring of the server. Without a
+// command the server is started.
+func NewCLI() *cli.App {
+	serve := serveCommand()
+
+	return &cli.App{
+		Name:   "api",
+		Usage:  "Song Library API",
+		Action: serve.Action,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "config", EnvVars: []string{"CONFIG_FILE"}, Usage: "YAML or TOML config file, config.yaml, config.yml or config.toml by default"},
+			&cli.StringFlag{Name: "profile", EnvVars: []string{"APP_PROFILE"}, Usage: "configuration profile (dev, test, prod)"},
+		},
+		Commands: []*cli.Command{
+			serve,
+			workerCommand(),
+			migrateCommand(),
+			importCommand(),
+			exportCommand(),
+			seedCommand(),
+			groupsCommand(),
+			refreshCommand(),
+			apiKeyCommand(),
+		},
+	}
+}
+
+func withApp(action func(c *cli.Context, a *App) error) cli.ActionFunc {
+	return func(c *cli.Context) (err error) {
+		a, err := New(c.String("config"), c.String("profile"))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := a.Close(); closeErr != nil {
+				err = stderrors.Join(err, closeErr)
+			}
+		}()
+
+		return action(c, a)
+	}
+}
+
+// withMusicInfo fails the commands that look songs up when the music info
+// service is not configured. The other commands run without it.
+func withMusicInfo(action func(c *cli.Context, a *App) error) func(c *cli.Context, a *App) error {
+	return func(c *cli.Context, a *App) error {
+		if err := a.provider.Config().RequireMusicInfo(); err != nil {
+			return err
+		}
+		return action(c, a)
+	}
+}
+
+func serveCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "serve",
+		Usage: "apply migrations and start the HTTP server",
+		Action: withApp(withMusicInfo(func(c *cli.Context, a *App) error {
+			return a.Run(c.Context)
+		})),
+	}
+}
+
+func workerCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "worker",
+		Usage: "process background jobs without serving HTTP",
+		Action: withApp(withMusicInfo(func(c *cli.Context, a *App) error {
+			return a.RunWorker(c.Context)
+		})),
+	}
+}
+
+func migrateCommand() *cli.Command {
+	stepsFlag := &cli.IntFlag{Name: "steps", Usage: "number of migrations, all if not set"}
+
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "manage database migrations",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "up",
+				Usage: "apply pending migrations",
+				Flags: []cli.Flag{stepsFlag},
+				Action: withApp(func(c *cli.Context, a *App) error {
+					if err := a.MigrateUp(c.Int("steps")); err != nil {
+						return err
+					}
+					return printMigrationVersion(c, a)
+				}),
+			},
+			{
+				Name:  "down",
+				Usage: "roll back applied migrations",
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "steps", Value: 1, Usage: "number of migrations, 0 rolls back all of them"},
+				},
+				Action: withApp(func(c *cli.Context, a *App) error {
+					if err := a.MigrateDown(c.Int("steps")); err != nil {
+						return err
+					}
+					return printMigrationVersion(c, a)
+				}),
+			},
+			{
+				Name:  "version",
+				Usage: "print the current migration version",
+				Action: withApp(func(c *cli.Context, a *App) error {
+					return printMigrationVersion(c, a)
+				}),
+			},
+			{
+				Name:      "force",
+				Usage:     "set the migration version without running migrations",
+				ArgsUsage: "<version>",
+				Action: withApp(func(c *cli.Context, a *App) error {
+					if c.NArg() != 1 {
+						return errors.New("exactly one version is required")
+					}
+					version, err := strconv.Atoi(c.Args().First())
+					if err != nil {
+						return errors.Wrap(err, "invalid version")
+					}
+					if err = a.MigrateForce(version); err != nil {
+						return err
+					}
+					return printMigrationVersion(c, a)
+				}),
+			},
+		},
+	}
+}
+
+func printMigrationVersion(c *cli.Context, a *App) error {
+	version, dirty, err := a.MigrationVersion()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.App.Writer, "version %d, dirty %t\n", version, dirty)
+	return err
+}
+
+func importCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "import",
+		Usage:     "import songs from a CSV, JSON or NDJSON file",
+		ArgsUsage: "<file>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "format", Usage: "file format (csv, json, ndjson), detected from the file name by default"},
+			&cli.StringSliceFlag{Name: "map", Usage: "map a song field to a column, e.g. --map group=Artist"},
+			&cli.BoolFlag{Name: "dry-run", Usage: "only validate rows without saving them"},
+			&cli.BoolFlag{Name: "enrich", Usage: "fill missing fields from the music info service"},
+			&cli.IntFlag{Name: "chunk-size", Usage: "rows saved in one transaction"},
+		},
+		Action: withApp(func(c *cli.Context, a *App) error {
+			if c.NArg() != 1 {
+				return errors.New("exactly one file is required")
+			}
+			if c.Bool("enrich") {
+				if err := a.provider.Config().RequireMusicInfo(); err != nil {
+					return err
+				}
+			}
+
+			mapping := importer.Mapping{}
+			for _, value := range c.StringSlice("map") {
+				field, column, ok := strings.Cut(value, "=")
+				if !ok || field == "" || column == "" {
+					return errors.Errorf("mapping %q must look like field=column", value)
+				}
+				mapping[field] = column
+			}
+
+			result, err := a.ImportFile(c.Context, c.Args().First(), c.String("format"), mapping, model.SongImportOptions{
+				DryRun:    c.Bool("dry-run"),
+				Enrich:    c.Bool("enrich"),
+				ChunkSize: c.Int("chunk-size"),
+			})
+			if err != nil {
+				// A cancelled import reports the chunks saved before it stopped.
+				if result != nil {
+					_ = printJSON(c.App.Writer, result)
+				}
+				return err
+			}
+
+			return printImport(c, result)
+		}),
+	}
+}
+
+func printImport(c *cli.Context, result *model.SongImport) error {
+	if err := printJSON(c.App.Writer, result); err != nil {
+		return err
+	}
+	if result.Failed > 0 {
+		return cli.Exit(fmt.Sprintf("%d of %d rows failed", result.Failed, result.Total), 1)
+	}
+	return nil
+}
+
+func exportCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "export",
+		Usage: "export songs to CSV, JSON or NDJSON",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "format", Value: "csv", Usage: "file format (csv, json, ndjson)"},
+			&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "output file, stdout by default"},
+			&cli.StringFlag{Name: "group", Usage: "filter by group name"},
+			&cli.StringFlag{Name: "song", Usage: "filter by song name"},
+			&cli.StringFlag{Name: "text", Usage: "filter by text"},
+			&cli.StringFlag{Name: "link", Usage: "filter by link"},
+			&cli.StringFlag{Name: "release-date", Usage: "filter by release date"},
+		},
+		Action: withApp(func(c *cli.Context, a *App) error {
+			output := c.App.Writer
+			if path := c.String("output"); path != "" {
+				file, err := os.Create(path)
+				if err != nil {
+					return errors.Wrap(err, "failed to create export file")
+				}
+				defer file.Close()
+				output = file
+			}
+
+			return a.Export(c.Context, output, c.String("format"), SongFilter{
+				Group:       c.String("group"),
+				Song:        c.String("song"),
+				Text:        c.String("text"),
+				Link:        c.String("link"),
+				ReleaseDate: c.String("release-date"),
+			})
+		}),
+	}
+}
+
+func seedCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "seed",
+		Usage: "add sample songs for local development, songs that exist already are skipped",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "dry-run", Usage: "only validate the sample songs"},
+		},
+		Action: withApp(func(c *cli.Context, a *App) error {
+			result, err := a.Seed(c.Context, model.SongImportOptions{DryRun: c.Bool("dry-run")})
+			if err != nil {
+				return err
+			}
+			return printImport(c, result)
+		}),
+	}
+}
+
+func groupsCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "groups",
+		Usage: "manage groups",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "merge",
+				Usage:     "move the songs of the source groups to the target group and delete the source groups",
+				ArgsUsage: "<source>...",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "into", Required: true, Usage: "target group name"},
+				},
+				Action: withApp(func(c *cli.Context, a *App) error {
+					if c.NArg() == 0 {
+						return errors.New("at least one source group is required")
+					}
+
+					merge, err := a.provider.GroupService().Merge(c.Context, c.String("into"), c.Args().Slice())
+					if err != nil {
+						return err
+					}
+					return printJSON(c.App.Writer, merge)
+				}),
+			},
+		},
+	}
+}
+
+func refreshCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "refresh",
+		Usage: "re-pull song metadata from the music info service",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "id", Usage: "song ID"},
+			&cli.StringFlag{Name: "group", Usage: "refresh every song of the group"},
+			&cli.BoolFlag{Name: "dry-run", Usage: "only report changes without saving them"},
+			&cli.BoolFlag{Name: "force", Usage: "overwrite manually edited fields"},
+		},
+		Action: withApp(withMusicInfo(func(c *cli.Context, a *App) error {
+			opts := model.SongRefreshOptions{
+				DryRun: c.Bool("dry-run"),
+				Force:  c.Bool("force"),
+			}
+
+			switch {
+			case c.IsSet("id") == c.IsSet("group"):
+				return errors.New("exactly one of --id and --group is required")
+			case c.IsSet("id"):
+				id, err := uuid.Parse(c.String("id"))
+				if err != nil {
+					return errors.Wrap(err, "invalid song ID")
+				}
+				refresh, err := a.provider.SongService().Refresh(c.Context, id, opts)
+				if err != nil {
+					return err
+				}
+				return printJSON(c.App.Writer, converter.ToViewFromSongRefresh(*refresh))
+			default:
+				refreshes, err := a.provider.SongService().RefreshGroup(c.Context, c.String("group"), opts)
+				if err != nil {
+					return err
+				}
+				return printJSON(c.App.Writer, converter.ToViewsFromSongRefresh(refreshes))
+			}
+		})),
+	}
+}
+
+func apiKeyCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "apikey",
+		Usage: "manage API keys",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "create",
+				Usage: "create an API key, the key is printed only once",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "name", Required: true, Usage: "name of the client"},
+				},
+				Action: withApp(func(c *cli.Context, a *App) error {
+					created, err := a.provider.APIKeyService().Create(c.Context, c.String("name"))
+					if err != nil {
+						return err
+					}
+					return printJSON(c.App.Writer, created)
+				}),
+			},
+			{
+				Name:      "revoke",
+				Usage:     "revoke an API key",
+				ArgsUsage: "<id>",
+				Action: withApp(func(c *cli.Context, a *App) error {
+					if c.NArg() != 1 {
+						return errors.New("exactly one API key ID is required")
+					}
+					id, err := uuid.Parse(c.Args().First())
+					if err != nil {
+						return errors.Wrap(err, "invalid API key ID")
+					}
+
+					apiKey, err := a.provider.APIKeyService().Revoke(c.Context, id)
+					if err != nil {
+						return err
+					}
+					return printJSON(c.App.Writer, apiKey)
+				}),
+			},
+		},
+	}
+}
+
+func printJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return errors.Wrap(encoder.Encode(v), "failed to write output")
+}
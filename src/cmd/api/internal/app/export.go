@@ -0,0 +1,76 @@
+package app
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"io"
+	"song-library-api/src/cmd/api/internal/exporter"
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/pkg/partial_date"
+)
+
+// SongFilter mirrors the query parameters of GET /songs. Empty values are
+// not filtered on.
+type SongFilter struct {
+	Group       string
+	Song        string
+	Text        string
+	Link        string
+	ReleaseDate string
+}
+
+// Export writes the songs matching filter to w, the command line counterpart
+// of GET /songs/export.
+func (a *App) Export(ctx context.Context, w io.Writer, format string, filter SongFilter) error {
+	parsedFormat, err := exporter.ParseFormat(format)
+	if err != nil {
+		return err
+	}
+
+	filters, err := a.toSongFilter(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	writer, err := exporter.NewWriter(w, parsedFormat, partial_date.Date.String)
+	if err != nil {
+		return err
+	}
+
+	if err = a.provider.SongService().Export(ctx, filters, writer.Write); err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+func (a *App) toSongFilter(ctx context.Context, filter SongFilter) (*model.SongFilter, error) {
+	filters := &model.SongFilter{}
+	optional := func(value string) *string {
+		if value == "" {
+			return nil
+		}
+		return &value
+	}
+	filters.Song = optional(filter.Song)
+	filters.Text = optional(filter.Text)
+	filters.Link = optional(filter.Link)
+
+	if filter.Group != "" {
+		group, err := a.provider.GroupService().GetByName(ctx, filter.Group)
+		if err != nil {
+			return nil, err
+		}
+		filters.GroupID = group.ID
+	}
+
+	if filter.ReleaseDate != "" {
+		releaseDate, err := partial_date.Parse(filter.ReleaseDate)
+		if err != nil {
+			return nil, errors.Wrap(model.ErrBadRequest, err.Error())
+		}
+		filters.ReleaseDate = &releaseDate
+	}
+
+	return filters, nil
+}
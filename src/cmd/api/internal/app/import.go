@@ -0,0 +1,37 @@
+package app
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"os"
+	"song-library-api/src/cmd/api/internal/importer"
+	"song-library-api/src/cmd/api/internal/model"
+)
+
+// ImportFile imports songs from a file, the command line counterpart of
+// POST /songs/import. The format is detected from the file name if empty.
+func (a *App) ImportFile(ctx context.Context,
+	path, format string,
+	mapping importer.Mapping,
+	opts model.SongImportOptions) (*model.SongImport, error) {
+	parsedFormat, err := importer.FormatFromFilename(path)
+	if format != "" {
+		parsedFormat, err = importer.ParseFormat(format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open import file")
+	}
+	defer file.Close()
+
+	rows, err := importer.Read(file, parsedFormat, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.provider.SongService().Import(ctx, rows, opts)
+}
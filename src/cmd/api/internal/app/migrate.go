@@ -0,0 +1,111 @@
+package app
+
+import (
+	"context"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/pkg/errors"
+	"song-library-api/src/cmd/api/migrations"
+)
+
+// ErrDirtyMigration means a migration failed halfway and the schema has to
+// be fixed by hand before the version is forced.
+var ErrDirtyMigration = errors.New("database migration state is dirty")
+
+// applyMigration runs pending migrations when auto-migrate is enabled. It
+// refuses to start on a dirty migration state either way.
+func (a *App) applyMigration(_ context.Context) error {
+	version, dirty, err := a.MigrationVersion()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return errors.Wrapf(ErrDirtyMigration,
+			"migration %d failed, fix the schema and run `migrate force <version>`", version)
+	}
+
+	if !a.provider.Config().AutoMigrate {
+		a.provider.Logger().Info("Auto-migrate disabled", "version", version)
+		return nil
+	}
+
+	if err = a.MigrateUp(0); err != nil {
+		return err
+	}
+
+	version, dirty, err = a.MigrationVersion()
+	if err != nil {
+		return err
+	}
+
+	a.provider.Logger().Info("Applied migration", "version", version, "dirty", dirty)
+
+	return nil
+}
+
+// MigrateUp applies the given number of migrations, or all pending ones if
+// steps is not positive.
+func (a *App) MigrateUp(steps int) error {
+	return a.withMigrate(func(m *migrate.Migrate) error {
+		if steps > 0 {
+			return m.Steps(steps)
+		}
+		return m.Up()
+	})
+}
+
+// MigrateDown rolls back the given number of migrations, or all of them if
+// steps is not positive.
+func (a *App) MigrateDown(steps int) error {
+	return a.withMigrate(func(m *migrate.Migrate) error {
+		if steps > 0 {
+			return m.Steps(-steps)
+		}
+		return m.Down()
+	})
+}
+
+// MigrateForce sets the version without running migrations, which clears
+// the dirty flag after a failed migration was fixed by hand.
+func (a *App) MigrateForce(version int) error {
+	return a.withMigrate(func(m *migrate.Migrate) error {
+		return m.Force(version)
+	})
+}
+
+// MigrationVersion returns zero if no migration was applied yet.
+func (a *App) MigrationVersion() (version uint, dirty bool, err error) {
+	err = a.withMigrate(func(m *migrate.Migrate) error {
+		version, dirty, err = m.Version()
+		if errors.Is(err, migrate.ErrNilVersion) {
+			return nil
+		}
+		return err
+	})
+	return version, dirty, err
+}
+
+func (a *App) withMigrate(fn func(m *migrate.Migrate) error) error {
+	// The migrator connects on its own and gives up at once.
+	if err := a.provider.WaitForPostgres(context.Background()); err != nil {
+		return err
+	}
+
+	source, err := iofs.New(migrations.FS, migrations.PostgreSQL)
+	if err != nil {
+		return errors.Wrap(err, "failed to read migrations")
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", source, a.provider.Config().PostgresConn)
+	if err != nil {
+		return errors.Wrap(err, "failed to init migrations")
+	}
+	defer m.Close()
+
+	if err = fn(m); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return errors.Wrap(err, "migration failed")
+	}
+
+	return nil
+}
@@ -2,24 +2,36 @@ package app
 
 import (
 	"context"
+	stderrors "errors"
 	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
 	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pkg/errors"
 	"log"
 	"log/slog"
+	"net/http"
 	"os"
 	"song-library-api/src/cmd/api/internal/config"
 	"song-library-api/src/cmd/api/internal/db/postgres"
+	"song-library-api/src/cmd/api/internal/health"
+	"song-library-api/src/cmd/api/internal/logging"
+	"song-library-api/src/cmd/api/internal/metrics"
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/cmd/api/internal/ratelimit"
 	"song-library-api/src/cmd/api/internal/repository"
 	"song-library-api/src/cmd/api/internal/service"
+	"song-library-api/src/cmd/api/internal/tracing"
+	"song-library-api/src/cmd/api/internal/worker"
+	"song-library-api/src/cmd/api/migrations"
 	"song-library-api/src/pkg/music_info_client"
+	"time"
 )
 
 type serviceProvider struct {
 	config *config.Config
 
 	logger *slog.Logger
+	tracer *tracing.Provider
 
 	postgres  *pgxpool.Pool
 	trManager *manager.Manager
@@ -31,43 +43,124 @@ type serviceProvider struct {
 
 	groupRepo    repository.GroupRepository
 	groupService service.GroupService
+
+	jobRepo    repository.JobRepository
+	jobService service.JobService
+	worker     *worker.Worker
+
+	apiKeyRepo    repository.APIKeyRepository
+	apiKeyService service.APIKeyService
+
+	healthChecker *health.Checker
+	rateLimiter   *ratelimit.Limiter
+	metrics       *metrics.Metrics
+
+	// closers release resources in reverse order of their creation.
+	closers []closer
 }
 
-func NewServiceProvider() *serviceProvider {
-	return &serviceProvider{}
+type closer struct {
+	name  string
+	close func() error
 }
 
-func (p *serviceProvider) Config() *config.Config {
-	if p.config == nil {
-		cfg, err := config.FromEnv()
-		if err != nil {
-			log.Fatal(errors.Wrap(err, "init config"))
+func NewServiceProvider(cfg *config.Config) *serviceProvider {
+	return &serviceProvider{config: cfg}
+}
+
+func (p *serviceProvider) addCloser(name string, close func() error) {
+	p.closers = append(p.closers, closer{name: name, close: close})
+}
+
+// Close releases the created resources, the most recently created first, so
+// that nothing is closed while a resource depending on it is still open.
+func (p *serviceProvider) Close() error {
+	var errs []error
+	for i := len(p.closers) - 1; i >= 0; i-- {
+		c := p.closers[i]
+		if err := c.close(); err != nil {
+			errs = append(errs, errors.Wrapf(err, "close %s", c.name))
+			continue
 		}
-		p.config = cfg
+		p.Logger().Debug("closed", "resource", c.name)
 	}
+	p.closers = nil
+
+	return stderrors.Join(errs...)
+}
+
+func (p *serviceProvider) Config() *config.Config {
 	return p.config
 }
 
 func (p *serviceProvider) Logger() *slog.Logger {
 	if p.logger == nil {
-		p.logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-			Level: slog.LevelDebug,
-		}))
+		cfg := p.Config()
+		handler, err := logging.NewHandler(os.Stdout, cfg.LogLevel, cfg.LogFormat)
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "init logger"))
+		}
+		p.logger = slog.New(logging.NewContextHandler(tracing.NewLogHandler(handler)))
 	}
 	return p.logger
 }
 
+// TracerProvider registers the global tracer provider. It is created first so
+// that it is closed last and flushes the spans of the shutdown.
+func (p *serviceProvider) TracerProvider() *tracing.Provider {
+	if p.tracer == nil {
+		cfg := p.Config()
+		tracer, err := tracing.NewProvider(context.Background(),
+			cfg.TracingExporter,
+			cfg.TracingServiceName,
+			cfg.TracingSampleRatio)
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "init tracer provider"))
+		}
+		p.tracer = tracer
+		p.addCloser("tracer provider", func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+			defer cancel()
+			return tracer.Shutdown(ctx)
+		})
+	}
+	return p.tracer
+}
+
 func (p *serviceProvider) Postgres() *pgxpool.Pool {
 	if p.postgres == nil {
-		db, err := postgres.NewDB(context.Background(), p.Config().PostgresConn)
+		cfg := p.Config()
+
+		db, err := postgres.NewDB(context.Background(), cfg.PostgresConn,
+			postgres.WithPoolSize(cfg.PostgresMaxConns, cfg.PostgresMinConns),
+			postgres.WithConnLifetime(cfg.PostgresMaxConnLifetime, cfg.PostgresMaxConnIdleTime, cfg.PostgresHealthCheckPeriod),
+			postgres.WithStatementTimeout(cfg.PostgresStatementTimeout),
+			postgres.WithConnectRetry(cfg.PostgresConnectTimeout, p.logPostgresRetry),
+			postgres.WithTracer(tracing.NewQueryTracer(cfg.TracingDBStatement)))
 		if err != nil {
 			log.Fatal(errors.Wrap(err, "init postgresql pool"))
 		}
 		p.postgres = db
+		p.addCloser("postgresql pool", func() error {
+			db.Close()
+			return nil
+		})
 	}
 	return p.postgres
 }
 
+// WaitForPostgres waits for the database to come up, for clients that do
+// not connect through the pool.
+func (p *serviceProvider) WaitForPostgres(ctx context.Context) error {
+	cfg := p.Config()
+	return postgres.WaitReady(ctx, cfg.PostgresConn,
+		postgres.WithConnectRetry(cfg.PostgresConnectTimeout, p.logPostgresRetry))
+}
+
+func (p *serviceProvider) logPostgresRetry(err error, wait time.Duration) {
+	p.Logger().Warn("postgresql is not ready", "error", err, "retryIn", wait)
+}
+
 func (p *serviceProvider) TransactionManager() *manager.Manager {
 	if p.trManager == nil {
 		p.trManager = manager.Must(trmpgx.NewDefaultFactory(p.postgres))
@@ -77,47 +170,225 @@ func (p *serviceProvider) TransactionManager() *manager.Manager {
 
 func (p *serviceProvider) MusicInfoClient() *music_info_client.MusicInfoClient {
 	if p.musicInfoClient == nil {
-		p.musicInfoClient = music_info_client.NewMusicInfoClient(p.Config().MusicInfoServiceURL)
+		cfg := p.Config()
+
+		opts := []music_info_client.Option{
+			music_info_client.WithBatchConcurrency(cfg.MusicInfoBatchConcurrency),
+			music_info_client.WithBatchEndpoint(cfg.MusicInfoBatchSize),
+			music_info_client.WithRateLimit(cfg.MusicInfoRateLimit, cfg.MusicInfoRateBurst),
+			music_info_client.WithMaxRetries(cfg.MusicInfoMaxRetries),
+			music_info_client.WithTimeout(cfg.MusicInfoTimeout),
+			music_info_client.WithUserAgent(cfg.MusicInfoUserAgent),
+			music_info_client.WithHealthPath(cfg.MusicInfoHealthPath),
+			music_info_client.WithLogger(p.Logger()),
+			music_info_client.WithTracing(),
+			music_info_client.WithResponseHook(p.logMusicInfoResponse),
+			music_info_client.WithResponseHook(p.Metrics().ObserveMusicInfoResponse),
+		}
+		if cfg.MusicInfoAPIKey != "" {
+			opts = append(opts, music_info_client.WithAPIKey(cfg.MusicInfoAPIKeyHeader, cfg.MusicInfoAPIKey))
+		}
+		if cfg.MusicInfoBearerToken != "" {
+			opts = append(opts, music_info_client.WithBearerToken(cfg.MusicInfoBearerToken))
+		}
+		if cfg.MusicInfoHMACSecret != "" {
+			opts = append(opts, music_info_client.WithHMACSigning(cfg.MusicInfoHMACKeyID, cfg.MusicInfoHMACSecret))
+		}
+		if cfg.MusicInfoCacheSize > 0 {
+			var cache music_info_client.Cache = music_info_client.NewLRUCache(cfg.MusicInfoCacheSize)
+			if cfg.MusicInfoCachePersistent {
+				cache = music_info_client.NewTieredCache(cache,
+					music_info_client.NewPostgresCache(p.Postgres(), "music_info_cache"))
+			}
+			opts = append(opts, music_info_client.WithCache(cache,
+				cfg.MusicInfoCacheTTL,
+				cfg.MusicInfoCacheNegativeTTL))
+		}
+
+		p.musicInfoClient = music_info_client.NewMusicInfoClient(cfg.MusicInfoServiceURL, opts...)
+		p.addCloser("music info client", p.musicInfoClient.Close)
+		p.Metrics().Register(metrics.NewMusicInfoStatsCollector(p.musicInfoClient))
 	}
 	return p.musicInfoClient
 }
 
+func (p *serviceProvider) logMusicInfoResponse(request *http.Request,
+	response *http.Response,
+	err error,
+	elapsed time.Duration) {
+	if err != nil {
+		p.Logger().WarnContext(request.Context(), "music info request failed",
+			"method", request.Method,
+			"path", request.URL.Path,
+			"elapsed", elapsed,
+			"error", err)
+		return
+	}
+
+	p.Logger().DebugContext(request.Context(), "music info request",
+		"method", request.Method,
+		"path", request.URL.Path,
+		"status", response.StatusCode,
+		"elapsed", elapsed)
+}
+
 func (p *serviceProvider) SongRepo() repository.SongRepository {
 	if p.songRepo == nil {
-		p.songRepo = repository.NewSongRepository(p.Postgres(),
-			trmpgx.DefaultCtxGetter,
-			p.TransactionManager())
+		p.songRepo = repository.NewSongRepositoryWithInstrumentation(
+			repository.NewSongRepository(p.Postgres(),
+				trmpgx.DefaultCtxGetter,
+				p.TransactionManager()),
+			p.Metrics())
 	}
 	return p.songRepo
 }
 
 func (p *serviceProvider) GroupRepo() repository.GroupRepository {
 	if p.groupRepo == nil {
-		p.groupRepo = repository.NewGroupRepository(p.Postgres(),
-			trmpgx.DefaultCtxGetter,
-			p.TransactionManager())
+		p.groupRepo = repository.NewGroupRepositoryWithInstrumentation(
+			repository.NewGroupRepository(p.Postgres(),
+				trmpgx.DefaultCtxGetter,
+				p.TransactionManager()),
+			p.Metrics())
 	}
 	return p.groupRepo
 }
 
 func (p *serviceProvider) SongService() service.SongService {
 	if p.songService == nil {
-		p.songService = service.NewSongService(
-			p.SongRepo(),
-			p.GroupRepo(),
-			p.MusicInfoClient(),
-			p.TransactionManager(),
-			p.Logger())
+		p.songService = service.NewSongServiceWithTracing(
+			service.NewSongService(
+				p.SongRepo(),
+				p.GroupRepo(),
+				p.JobService(),
+				p.Metrics(),
+				p.MusicInfoClient(),
+				p.TransactionManager(),
+				p.Logger()))
 	}
 	return p.songService
 }
 
 func (p *serviceProvider) GroupService() service.GroupService {
 	if p.groupService == nil {
-		p.groupService = service.NewGroupService(
-			p.GroupRepo(),
-			p.TransactionManager(),
-			p.Logger())
+		p.groupService = service.NewGroupServiceWithTracing(
+			service.NewGroupService(
+				p.GroupRepo(),
+				p.SongRepo(),
+				p.TransactionManager(),
+				p.Logger()))
 	}
 	return p.groupService
 }
+
+func (p *serviceProvider) JobRepo() repository.JobRepository {
+	if p.jobRepo == nil {
+		p.jobRepo = repository.NewJobRepositoryWithInstrumentation(
+			repository.NewJobRepository(p.Postgres(),
+				trmpgx.DefaultCtxGetter,
+				p.TransactionManager()),
+			p.Metrics())
+	}
+	return p.jobRepo
+}
+
+func (p *serviceProvider) JobService() service.JobService {
+	if p.jobService == nil {
+		p.jobService = service.NewJobServiceWithTracing(
+			service.NewJobService(
+				p.JobRepo(),
+				p.Config().JobMaxAttempts,
+				p.Logger()))
+	}
+	return p.jobService
+}
+
+func (p *serviceProvider) Worker() *worker.Worker {
+	if p.worker == nil {
+		cfg := p.Config()
+		p.worker = worker.NewWorker(
+			p.JobRepo(),
+			cfg.WorkerConcurrency,
+			cfg.WorkerPollInterval,
+			cfg.WorkerLockTimeout,
+			cfg.JobRetryBackoff,
+			p.Logger())
+		p.worker.Register(model.JobTypeSongEnrich, worker.NewSongEnrichHandler(p.SongService()))
+	}
+	return p.worker
+}
+
+func (p *serviceProvider) APIKeyRepo() repository.APIKeyRepository {
+	if p.apiKeyRepo == nil {
+		p.apiKeyRepo = repository.NewAPIKeyRepositoryWithInstrumentation(
+			repository.NewAPIKeyRepository(p.Postgres(),
+				trmpgx.DefaultCtxGetter,
+				p.TransactionManager()),
+			p.Metrics())
+	}
+	return p.apiKeyRepo
+}
+
+func (p *serviceProvider) APIKeyService() service.APIKeyService {
+	if p.apiKeyService == nil {
+		p.apiKeyService = service.NewAPIKeyServiceWithTracing(
+			service.NewAPIKeyService(
+				p.APIKeyRepo(),
+				p.Logger()))
+	}
+	return p.apiKeyService
+}
+
+func (p *serviceProvider) HealthChecker() *health.Checker {
+	if p.healthChecker == nil {
+		cfg := p.Config()
+
+		latest, err := migrations.Latest(migrations.PostgreSQL)
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "init health checker"))
+		}
+
+		p.healthChecker = health.NewChecker(cfg.HealthCheckTimeout)
+		p.healthChecker.Register("postgres", health.PostgresCheck(p.Postgres()))
+		p.healthChecker.Register("migrations", health.MigrationCheck(p.Postgres(), latest))
+		if cfg.HealthCheckMusicInfo {
+			p.healthChecker.Register("music-info", p.MusicInfoClient().Ping)
+		}
+	}
+	return p.healthChecker
+}
+
+func (p *serviceProvider) Metrics() *metrics.Metrics {
+	if p.metrics == nil {
+		p.metrics = metrics.New()
+		p.metrics.Register(metrics.NewPoolCollector(p.Postgres()))
+	}
+	return p.metrics
+}
+
+func (p *serviceProvider) RateLimiter() *ratelimit.Limiter {
+	if p.rateLimiter == nil {
+		cfg := p.Config()
+
+		limits := make(map[ratelimit.Class]ratelimit.Limit)
+		if cfg.RateLimitEnabled {
+			limits[ratelimit.ClassRead] = ratelimit.Limit{Requests: cfg.RateLimitRead, Window: cfg.RateLimitWindow}
+			limits[ratelimit.ClassWrite] = ratelimit.Limit{Requests: cfg.RateLimitWrite, Window: cfg.RateLimitWindow}
+			limits[ratelimit.ClassSearch] = ratelimit.Limit{Requests: cfg.RateLimitSearch, Window: cfg.RateLimitWindow}
+			limits[ratelimit.ClassAuth] = ratelimit.Limit{Requests: cfg.RateLimitAuth, Window: cfg.RateLimitWindow}
+		}
+
+		var store ratelimit.Store
+		switch cfg.RateLimitStore {
+		case ratelimit.StoreMemory:
+			store = ratelimit.NewMemoryStore()
+		case ratelimit.StorePostgres:
+			store = ratelimit.NewPostgresStore(p.Postgres(), "rate_limit")
+		default:
+			log.Fatalf("init rate limiter: unknown store %q", cfg.RateLimitStore)
+		}
+
+		p.rateLimiter = ratelimit.NewLimiter(store, limits)
+	}
+	return p.rateLimiter
+}
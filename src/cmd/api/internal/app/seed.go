@@ -0,0 +1,25 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"song-library-api/src/cmd/api/internal/importer"
+	"song-library-api/src/cmd/api/internal/model"
+)
+
+// seedSongs is sample data for local development. The lyrics are placeholders.
+//
+//go:embed seed/songs.ndjson
+var seedSongs []byte
+
+// Seed imports the sample songs. Import skips the songs that exist already,
+// so seeding again adds nothing.
+func (a *App) Seed(ctx context.Context, opts model.SongImportOptions) (*model.SongImport, error) {
+	rows, err := importer.Read(bytes.NewReader(seedSongs), importer.FormatNDJSON, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.provider.SongService().Import(ctx, rows, opts)
+}
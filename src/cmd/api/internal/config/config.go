@@ -1,22 +1,122 @@
 package config
 
 import (
-	"github.com/joho/godotenv"
-	"github.com/kelseyhightower/envconfig"
 	"github.com/pkg/errors"
+	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
-	ServerAddress       string `envconfig:"SERVER_ADDRESS"`
-	PostgresConn        string `envconfig:"POSTGRES_CONN"`
-	Username            string `envconfig:"POSTGRES_USERNAME"`
-	Password            string `envconfig:"POSTGRES_PASSWORD"`
-	Host                string `envconfig:"POSTGRES_HOST"`
-	Port                uint16 `envconfig:"POSTGRES_PORT"`
-	Database            string `envconfig:"POSTGRES_DATABASE"`
+	// Profile is dev, test, prod or empty. It selects a set of defaults and
+	// the config.<profile> file.
+	Profile string `envconfig:"APP_PROFILE"`
+
+	ServerAddress string `envconfig:"SERVER_ADDRESS" default:":8080"`
+	// Zero timeouts do not limit, long exports need an unlimited write timeout.
+	ServerReadTimeout       time.Duration `envconfig:"SERVER_READ_TIMEOUT" default:"30s"`
+	ServerReadHeaderTimeout time.Duration `envconfig:"SERVER_READ_HEADER_TIMEOUT" default:"5s"`
+	ServerWriteTimeout      time.Duration `envconfig:"SERVER_WRITE_TIMEOUT"`
+	ServerIdleTimeout       time.Duration `envconfig:"SERVER_IDLE_TIMEOUT" default:"2m"`
+	// ServerRequestTimeout is the deadline of a request, its queries included.
+	// Export, import and group refresh get ServerBulkRequestTimeout instead.
+	ServerRequestTimeout     time.Duration `envconfig:"SERVER_REQUEST_TIMEOUT" default:"30s"`
+	ServerBulkRequestTimeout time.Duration `envconfig:"SERVER_BULK_REQUEST_TIMEOUT" default:"10m"`
+	// ServerBodyLimit caps request bodies, for example 10M.
+	ServerBodyLimit string `envconfig:"SERVER_BODY_LIMIT" default:"32M"`
+
+	// CORSAllowedOrigins enables CORS for the listed origins, * for any.
+	CORSAllowedOrigins   []string `envconfig:"CORS_ALLOWED_ORIGINS"`
+	CORSAllowedHeaders   []string `envconfig:"CORS_ALLOWED_HEADERS" default:"Content-Type,X-API-Key,X-Request-ID,Authorization"`
+	CORSAllowCredentials bool     `envconfig:"CORS_ALLOW_CREDENTIALS"`
+	CORSMaxAge           int      `envconfig:"CORS_MAX_AGE" default:"600"`
+
+	// PostgresConn may contain {POSTGRES_*} placeholders for the fields below.
+	PostgresConn     string `envconfig:"POSTGRES_CONN" default:"postgres://{POSTGRES_USERNAME}:{POSTGRES_PASSWORD}@{POSTGRES_HOST}:{POSTGRES_PORT}/{POSTGRES_DATABASE}?sslmode=disable"`
+	Username         string `envconfig:"POSTGRES_USERNAME"`
+	Password         string `envconfig:"POSTGRES_PASSWORD"`
+	Host             string `envconfig:"POSTGRES_HOST" default:"localhost"`
+	Port             uint16 `envconfig:"POSTGRES_PORT" default:"5432"`
+	Database         string `envconfig:"POSTGRES_DATABASE"`
+	PostgresMaxConns int32  `envconfig:"POSTGRES_MAX_CONNS" default:"10"`
+	PostgresMinConns int32  `envconfig:"POSTGRES_MIN_CONNS"`
+
+	PostgresMaxConnLifetime   time.Duration `envconfig:"POSTGRES_MAX_CONN_LIFETIME" default:"1h"`
+	PostgresMaxConnIdleTime   time.Duration `envconfig:"POSTGRES_MAX_CONN_IDLE_TIME" default:"30m"`
+	PostgresHealthCheckPeriod time.Duration `envconfig:"POSTGRES_HEALTH_CHECK_PERIOD" default:"1m"`
+	// PostgresStatementTimeout aborts single statements on the server, zero
+	// keeps the server setting.
+	PostgresStatementTimeout time.Duration `envconfig:"POSTGRES_STATEMENT_TIMEOUT" default:"30s"`
+	// PostgresConnectTimeout is how long startup waits for the database to
+	// come up, zero fails on the first attempt.
+	PostgresConnectTimeout time.Duration `envconfig:"POSTGRES_CONNECT_TIMEOUT" default:"30s"`
+
 	MusicInfoServiceURL string `envconfig:"MUSIC_INFO_SERVICE_URL"`
+	// MusicInfoTimeout bounds a single upstream round trip.
+	MusicInfoTimeout time.Duration `envconfig:"MUSIC_INFO_TIMEOUT" default:"10s"`
+
+	// LogLevel is debug, info, warn or error, LogFormat is json or text.
+	LogLevel  string `envconfig:"LOG_LEVEL" default:"info"`
+	LogFormat string `envconfig:"LOG_FORMAT" default:"json"`
+
+	// ShutdownTimeout bounds draining in-flight requests and stopping the worker.
+	ShutdownTimeout time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"15s"`
+	// ShutdownDelay keeps serving after readiness turned false, so that the
+	// load balancer notices before connections are drained.
+	ShutdownDelay time.Duration `envconfig:"SHUTDOWN_DELAY"`
+
+	HealthCheckTimeout   time.Duration `envconfig:"HEALTH_CHECK_TIMEOUT" default:"2s"`
+	HealthCheckMusicInfo bool          `envconfig:"HEALTH_CHECK_MUSIC_INFO"`
+
+	// TrustProxyHeaders takes the client IP from X-Forwarded-For and X-Real-IP.
+	// Enable it only behind a proxy that sets them, as clients can forge them.
+	TrustProxyHeaders bool `envconfig:"TRUST_PROXY_HEADERS"`
+
+	// RateLimitStore is memory or postgres, the latter shares the budgets
+	// between instances. A zero budget disables the limit of its class.
+	RateLimitEnabled bool          `envconfig:"RATE_LIMIT_ENABLED" default:"true"`
+	RateLimitStore   string        `envconfig:"RATE_LIMIT_STORE" default:"memory"`
+	RateLimitWindow  time.Duration `envconfig:"RATE_LIMIT_WINDOW" default:"1m"`
+	RateLimitRead    int           `envconfig:"RATE_LIMIT_READ" default:"300"`
+	RateLimitWrite   int           `envconfig:"RATE_LIMIT_WRITE" default:"60"`
+	RateLimitSearch  int           `envconfig:"RATE_LIMIT_SEARCH" default:"30"`
+	RateLimitAuth    int           `envconfig:"RATE_LIMIT_AUTH" default:"600"`
+
+	// AutoMigrate applies pending migrations when the server or worker starts.
+	AutoMigrate bool `envconfig:"AUTO_MIGRATE" default:"true"`
+
+	// TracingExporter is otlp, stdout or empty to record no spans. The OTLP
+	// endpoint is read from OTEL_EXPORTER_OTLP_ENDPOINT.
+	TracingExporter    string  `envconfig:"TRACING_EXPORTER"`
+	TracingServiceName string  `envconfig:"TRACING_SERVICE_NAME" default:"song-library-api"`
+	TracingSampleRatio float64 `envconfig:"TRACING_SAMPLE_RATIO" default:"1"`
+	// TracingDBStatement records the SQL of queries, values included.
+	TracingDBStatement bool `envconfig:"TRACING_DB_STATEMENT"`
+
+	MusicInfoCacheSize        int           `envconfig:"MUSIC_INFO_CACHE_SIZE" default:"1000"`
+	MusicInfoCacheTTL         time.Duration `envconfig:"MUSIC_INFO_CACHE_TTL" default:"1h"`
+	MusicInfoCacheNegativeTTL time.Duration `envconfig:"MUSIC_INFO_CACHE_NEGATIVE_TTL" default:"1m"`
+	MusicInfoCachePersistent  bool          `envconfig:"MUSIC_INFO_CACHE_PERSISTENT"`
+	MusicInfoBatchConcurrency int           `envconfig:"MUSIC_INFO_BATCH_CONCURRENCY" default:"4"`
+	MusicInfoBatchSize        int           `envconfig:"MUSIC_INFO_BATCH_SIZE"`
+	MusicInfoRateLimit        float64       `envconfig:"MUSIC_INFO_RATE_LIMIT"`
+	MusicInfoRateBurst        int           `envconfig:"MUSIC_INFO_RATE_BURST" default:"1"`
+	MusicInfoMaxRetries       int           `envconfig:"MUSIC_INFO_MAX_RETRIES" default:"3"`
+	MusicInfoUserAgent        string        `envconfig:"MUSIC_INFO_USER_AGENT" default:"song-library-api"`
+	MusicInfoHealthPath       string        `envconfig:"MUSIC_INFO_HEALTH_PATH" default:"/health"`
+	MusicInfoAPIKeyHeader     string        `envconfig:"MUSIC_INFO_API_KEY_HEADER"`
+	MusicInfoAPIKey           string        `envconfig:"MUSIC_INFO_API_KEY"`
+	MusicInfoBearerToken      string        `envconfig:"MUSIC_INFO_BEARER_TOKEN"`
+	MusicInfoHMACKeyID        string        `envconfig:"MUSIC_INFO_HMAC_KEY_ID"`
+	MusicInfoHMACSecret       string        `envconfig:"MUSIC_INFO_HMAC_SECRET"`
+
+	WorkerEnabled      bool          `envconfig:"WORKER_ENABLED" default:"true"`
+	WorkerConcurrency  int           `envconfig:"WORKER_CONCURRENCY" default:"1"`
+	WorkerPollInterval time.Duration `envconfig:"WORKER_POLL_INTERVAL" default:"1s"`
+	WorkerLockTimeout  time.Duration `envconfig:"WORKER_LOCK_TIMEOUT" default:"5m"`
+	JobMaxAttempts     int           `envconfig:"JOB_MAX_ATTEMPTS" default:"5"`
+	JobRetryBackoff    time.Duration `envconfig:"JOB_RETRY_BACKOFF" default:"10s"`
 }
 
 func (c *Config) initPostgresConn() {
@@ -29,20 +129,54 @@ func (c *Config) initPostgresConn() {
 	c.PostgresConn = replacer.Replace(c.PostgresConn)
 }
 
-func Load() error {
-	if err := godotenv.Load(); err != nil {
-		return errors.Wrap(err, "Error loading .env file")
+// Load layers the configuration sources. From lowest to highest precedence
+// they are the field defaults, the profile defaults, the config file, its
+// profile variant, the .env file and the environment. Variables ending in
+// _FILE name files to read secrets from, they take the place of the variable
+// in their layer. The file and profile may be empty, the profile is then
+// taken from APP_PROFILE. The process environment is not modified.
+func Load(file, profile string) (*Config, error) {
+	if profile == "" {
+		profile = os.Getenv("APP_PROFILE")
+	}
+	profileDefaults, ok := profiles[profile]
+	if profile != "" && !ok {
+		return nil, errors.Errorf("unknown profile %q, use dev, test or prod", profile)
+	}
+
+	file, err := findFile(file)
+	if err != nil {
+		return nil, err
+	}
+	fileVars, err := readFile(file)
+	if err != nil {
+		return nil, err
+	}
+	profileVars, err := readFile(profileFile(file, profile))
+	if err != nil {
+		return nil, err
+	}
+	dotEnvVars, err := readDotEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := merge(profileDefaults, fileVars, profileVars, dotEnvVars, environ())
+	if err != nil {
+		return nil, err
 	}
-	return nil
-}
 
-func FromEnv() (*Config, error) {
 	cfg := &Config{}
-	if err := envconfig.Process("", cfg); err != nil {
+	if err = decode(cfg, vars); err != nil {
 		return nil, errors.Wrap(err, "init config")
 	}
 
+	cfg.Profile = profile
 	cfg.initPostgresConn()
 
+	if err = cfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
@@ -0,0 +1,265 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// chdir runs the test in dir, so that no config or .env file of the working
+// directory is picked up.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+	})
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		files   map[string]string
+		env     map[string]string
+		check   func(t *testing.T, cfg *Config)
+		wantErr string
+	}{
+		{
+			name: "field defaults",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.ServerAddress != ":8080" || cfg.LogLevel != "info" || cfg.RateLimitAuth != 600 {
+					t.Errorf("defaults not applied: %q %q %d", cfg.ServerAddress, cfg.LogLevel, cfg.RateLimitAuth)
+				}
+				if cfg.PostgresConn != "postgres://:@localhost:5432/?sslmode=disable" {
+					t.Errorf("PostgresConn = %q", cfg.PostgresConn)
+				}
+				if !cfg.WorkerEnabled {
+					t.Error("WorkerEnabled = false, want true")
+				}
+			},
+		},
+		{
+			name:    "profile defaults override field defaults",
+			profile: ProfileDev,
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Profile != ProfileDev || cfg.LogLevel != "debug" || cfg.LogFormat != "text" {
+					t.Errorf("profile defaults not applied: %q %q %q", cfg.Profile, cfg.LogLevel, cfg.LogFormat)
+				}
+			},
+		},
+		{
+			name: "profile from the environment",
+			env:  map[string]string{"APP_PROFILE": ProfileTest},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Profile != ProfileTest || cfg.RateLimitEnabled || cfg.WorkerEnabled {
+					t.Errorf("test profile not applied: %q %t %t", cfg.Profile, cfg.RateLimitEnabled, cfg.WorkerEnabled)
+				}
+			},
+		},
+		{
+			name:    "file overrides profile defaults",
+			profile: ProfileDev,
+			files:   map[string]string{"config.yaml": "log:\n  level: warn\n"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.LogLevel != "warn" || cfg.LogFormat != "text" {
+					t.Errorf("LogLevel, LogFormat = %q, %q, want warn, text", cfg.LogLevel, cfg.LogFormat)
+				}
+			},
+		},
+		{
+			name:    "profile file overrides file",
+			profile: ProfileDev,
+			files: map[string]string{
+				"config.yaml":     "log:\n  level: warn\nserver:\n  address: :9000\n",
+				"config.dev.yaml": "log:\n  level: error\n",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.LogLevel != "error" || cfg.ServerAddress != ":9000" {
+					t.Errorf("LogLevel, ServerAddress = %q, %q, want error, :9000", cfg.LogLevel, cfg.ServerAddress)
+				}
+			},
+		},
+		{
+			name:    ".env overrides profile file",
+			profile: ProfileDev,
+			files: map[string]string{
+				"config.yaml":     "log:\n  level: warn\n",
+				"config.dev.yaml": "log:\n  level: error\n",
+				".env":            "LOG_LEVEL=info\n",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.LogLevel != "info" {
+					t.Errorf("LogLevel = %q, want info", cfg.LogLevel)
+				}
+			},
+		},
+		{
+			name:  "environment overrides .env",
+			files: map[string]string{".env": "LOG_LEVEL=warn\n"},
+			env:   map[string]string{"LOG_LEVEL": "error"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.LogLevel != "error" {
+					t.Errorf("LogLevel = %q, want error", cfg.LogLevel)
+				}
+			},
+		},
+		{
+			name: "secret file of a higher layer overrides the value",
+			files: map[string]string{
+				"config.yaml": "postgres:\n  password: from-file\n",
+				"password":    "from-secret\n",
+			},
+			env: map[string]string{"POSTGRES_PASSWORD_FILE": "password"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Password != "from-secret" {
+					t.Errorf("Password = %q, want from-secret", cfg.Password)
+				}
+			},
+		},
+		{
+			name: "value of a higher layer overrides the secret file",
+			files: map[string]string{
+				"config.yaml": "postgres:\n  password_file: password\n",
+				"password":    "from-secret",
+			},
+			env: map[string]string{"POSTGRES_PASSWORD": "from-env"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Password != "from-env" {
+					t.Errorf("Password = %q, want from-env", cfg.Password)
+				}
+			},
+		},
+		{
+			name:    "value and secret file in the same layer",
+			files:   map[string]string{"password": "from-secret"},
+			env:     map[string]string{"POSTGRES_PASSWORD": "from-env", "POSTGRES_PASSWORD_FILE": "password"},
+			wantErr: "both POSTGRES_PASSWORD and POSTGRES_PASSWORD_FILE are set",
+		},
+		{
+			name:    "missing secret file",
+			env:     map[string]string{"POSTGRES_PASSWORD_FILE": "missing"},
+			wantErr: "read POSTGRES_PASSWORD_FILE",
+		},
+		{
+			name: "lists from yaml",
+			files: map[string]string{
+				"config.yml": "cors:\n  allowed_origins:\n    - https://a.example\n    - https://b.example\n",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				want := []string{"https://a.example", "https://b.example"}
+				if !reflect.DeepEqual(cfg.CORSAllowedOrigins, want) {
+					t.Errorf("CORSAllowedOrigins = %q, want %q", cfg.CORSAllowedOrigins, want)
+				}
+			},
+		},
+		{
+			name:  "toml",
+			files: map[string]string{"config.toml": "[rate_limit]\nread = 10\nwindow = \"30s\"\n"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.RateLimitRead != 10 || cfg.RateLimitWindow.String() != "30s" {
+					t.Errorf("RateLimitRead, RateLimitWindow = %d, %s, want 10, 30s", cfg.RateLimitRead, cfg.RateLimitWindow)
+				}
+			},
+		},
+		{
+			name: "empty list",
+			env:  map[string]string{"CORS_ALLOWED_HEADERS": ""},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.CORSAllowedHeaders == nil || len(cfg.CORSAllowedHeaders) != 0 {
+					t.Errorf("CORSAllowedHeaders = %q, want an empty list", cfg.CORSAllowedHeaders)
+				}
+			},
+		},
+		{
+			name:    "unknown setting in file",
+			files:   map[string]string{"config.yaml": "server:\n  adress: :9000\n"},
+			wantErr: "unknown settings SERVER_ADRESS",
+		},
+		{
+			name:    "invalid value",
+			env:     map[string]string{"SERVER_READ_TIMEOUT": "soon"},
+			wantErr: `SERVER_READ_TIMEOUT="soon"`,
+		},
+		{
+			name:    "unknown profile",
+			profile: "staging",
+			wantErr: `unknown profile "staging"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			chdir(t, dir)
+
+			t.Setenv("APP_PROFILE", "")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := Load("", tt.profile)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoadDoesNotModifyEnvironment(t *testing.T) {
+	if _, ok := os.LookupEnv("LOG_LEVEL"); ok {
+		t.Skip("LOG_LEVEL is set in the environment")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("LOG_LEVEL=warn\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, dir)
+	t.Setenv("APP_PROFILE", "")
+
+	if _, err := Load("", ""); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, ok := os.LookupEnv("LOG_LEVEL"); ok {
+		t.Error("Load() set LOG_LEVEL in the environment")
+	}
+}
+
+func TestLoadExplicitFile(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	t.Setenv("APP_PROFILE", "")
+
+	if _, err := Load("missing.yaml", ""); err == nil {
+		t.Error("Load() of a missing file succeeded")
+	}
+
+	if err := os.WriteFile("settings.json", []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load("settings.json", ""); err == nil || !strings.Contains(err.Error(), "unsupported format") {
+		t.Errorf("Load() error = %v, want an unsupported format", err)
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+const (
+	ProfileDev  = "dev"
+	ProfileTest = "test"
+	ProfileProd = "prod"
+)
+
+// profiles hold the defaults of each profile. They take precedence over the
+// field defaults and are overridden by every other source.
+var profiles = map[string]map[string]string{
+	ProfileDev: {
+		"LOG_LEVEL":  "debug",
+		"LOG_FORMAT": "text",
+	},
+	ProfileTest: {
+		"LOG_LEVEL":          "warn",
+		"RATE_LIMIT_ENABLED": "false",
+		"WORKER_ENABLED":     "false",
+	},
+	ProfileProd: {
+		"LOG_LEVEL":  "info",
+		"LOG_FORMAT": "json",
+	},
+}
@@ -0,0 +1,283 @@
+package config
+
+import (
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const secretFileSuffix = "_FILE"
+
+// defaultFiles are looked up in the working directory when no config file
+// is given. A missing file is not an error.
+var defaultFiles = []string{"config.yaml", "config.yml", "config.toml"}
+
+// keys returns the environment variable names of the Config fields.
+func keys() map[string]struct{} {
+	result := make(map[string]struct{})
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		if key := t.Field(i).Tag.Get("envconfig"); key != "" {
+			result[key] = struct{}{}
+		}
+	}
+	return result
+}
+
+// findFile returns the explicitly given file or the first default file
+// that exists, and an empty string if there is none.
+func findFile(file string) (string, error) {
+	if file != "" {
+		if _, err := os.Stat(file); err != nil {
+			return "", errors.Wrap(err, "config file")
+		}
+		return file, nil
+	}
+
+	for _, name := range defaultFiles {
+		if _, err := os.Stat(name); err == nil {
+			return name, nil
+		}
+	}
+	return "", nil
+}
+
+// profileFile returns the profile variant of the file, config.prod.yaml for
+// config.yaml, if it exists.
+func profileFile(file, profile string) string {
+	if file == "" || profile == "" {
+		return ""
+	}
+
+	ext := filepath.Ext(file)
+	name := strings.TrimSuffix(file, ext) + "." + profile + ext
+	if _, err := os.Stat(name); err != nil {
+		return ""
+	}
+	return name
+}
+
+// readFile reads a YAML or TOML file into variables. Nested tables are
+// joined with underscores, so that server.address sets SERVER_ADDRESS, and
+// lists are joined with commas.
+func readFile(file string) (map[string]string, error) {
+	if file == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "read config file")
+	}
+
+	var tree map[string]any
+	switch ext := strings.ToLower(filepath.Ext(file)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, errors.Errorf("config file %s: unsupported format %q, use .yaml, .yml or .toml", file, ext)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse config file %s", file)
+	}
+
+	vars := make(map[string]string)
+	flatten("", tree, vars)
+
+	known := keys()
+	var unknown []string
+	for key := range vars {
+		if _, ok := known[strings.TrimSuffix(key, secretFileSuffix)]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, errors.Errorf("config file %s: unknown settings %s", file, strings.Join(unknown, ", "))
+	}
+
+	return vars, nil
+}
+
+func flatten(prefix string, value any, vars map[string]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		for name, child := range v {
+			key := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+			if prefix != "" {
+				key = prefix + "_" + key
+			}
+			flatten(key, child, vars)
+		}
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fmt.Sprint(item))
+		}
+		vars[prefix] = strings.Join(items, ",")
+	case nil:
+	default:
+		vars[prefix] = fmt.Sprint(v)
+	}
+}
+
+// readDotEnv reads the optional .env file of the working directory.
+func readDotEnv() (map[string]string, error) {
+	vars, err := godotenv.Read()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "read .env file")
+	}
+	return vars, nil
+}
+
+// environ returns the variables of the process environment.
+func environ() map[string]string {
+	vars := make(map[string]string)
+	for _, entry := range os.Environ() {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			vars[key] = value
+		}
+	}
+	return vars
+}
+
+// merge layers the variables, lowest precedence first. A variable X_FILE
+// names a file to read X from, it sets X in its layer like X itself would,
+// so a higher layer overrides it and it overrides the lower ones. Setting
+// both in the same layer is an error.
+func merge(layers ...map[string]string) (map[string]string, error) {
+	type source struct {
+		value string
+		file  bool
+	}
+
+	known := keys()
+	sources := make(map[string]source)
+	for _, layer := range layers {
+		for key, value := range layer {
+			name, isFile := strings.CutSuffix(key, secretFileSuffix)
+			if _, ok := known[name]; !isFile || !ok {
+				sources[key] = source{value: value}
+				continue
+			}
+			if _, ok := layer[name]; ok {
+				return nil, errors.Errorf("both %s and %s are set", name, key)
+			}
+			sources[name] = source{value: value, file: true}
+		}
+	}
+
+	vars := make(map[string]string, len(sources))
+	for key, src := range sources {
+		if !src.file {
+			vars[key] = src.value
+			continue
+		}
+
+		data, err := os.ReadFile(src.value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read %s%s", key, secretFileSuffix)
+		}
+		vars[key] = strings.TrimRight(string(data), "\r\n")
+	}
+	return vars, nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// decode sets the fields of cfg from the variables named by their envconfig
+// tags, falling back to the default tags. envconfig only reads the process
+// environment, so the merged layers are decoded here following its rules:
+// lists are comma separated and an empty list variable gives an empty list.
+func decode(cfg *Config, vars map[string]string) error {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("envconfig")
+		if key == "" {
+			continue
+		}
+
+		value, ok := vars[key]
+		if !ok {
+			if value, ok = field.Tag.Lookup("default"); !ok {
+				continue
+			}
+		}
+
+		if err := decodeValue(v.Field(i), value); err != nil {
+			return errors.Wrapf(err, "%s=%q", key, value)
+		}
+	}
+	return nil
+}
+
+func decodeValue(field reflect.Value, value string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		items := reflect.MakeSlice(field.Type(), 0, 0)
+		if strings.TrimSpace(value) != "" {
+			for _, item := range strings.Split(value, ",") {
+				elem := reflect.New(field.Type().Elem()).Elem()
+				if err := decodeValue(elem, item); err != nil {
+					return err
+				}
+				items = reflect.Append(items, elem)
+			}
+		}
+		field.Set(items)
+	default:
+		return errors.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	stderrors "errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/gommon/bytes"
+	"github.com/pkg/errors"
+	"net/url"
+	"slices"
+	"song-library-api/src/cmd/api/internal/logging"
+	"song-library-api/src/cmd/api/internal/ratelimit"
+	"song-library-api/src/cmd/api/internal/tracing"
+	"song-library-api/src/pkg/music_info_client"
+	"strings"
+	"time"
+)
+
+// Validate reports every invalid setting at once, each by its variable name.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.check(c.ServerAddress != "", "SERVER_ADDRESS", "is required")
+	v.nonNegative("SERVER_READ_TIMEOUT", c.ServerReadTimeout)
+	v.nonNegative("SERVER_READ_HEADER_TIMEOUT", c.ServerReadHeaderTimeout)
+	v.nonNegative("SERVER_WRITE_TIMEOUT", c.ServerWriteTimeout)
+	v.nonNegative("SERVER_IDLE_TIMEOUT", c.ServerIdleTimeout)
+	v.nonNegative("SERVER_REQUEST_TIMEOUT", c.ServerRequestTimeout)
+	v.nonNegative("SERVER_BULK_REQUEST_TIMEOUT", c.ServerBulkRequestTimeout)
+	if _, err := bytes.Parse(c.ServerBodyLimit); err != nil {
+		v.add("SERVER_BODY_LIMIT", "must be a size such as 10M")
+	}
+	v.positive("SHUTDOWN_TIMEOUT", c.ShutdownTimeout)
+	v.nonNegative("SHUTDOWN_DELAY", c.ShutdownDelay)
+	v.positive("HEALTH_CHECK_TIMEOUT", c.HealthCheckTimeout)
+
+	for _, origin := range c.CORSAllowedOrigins {
+		if origin == "*" {
+			v.check(!c.CORSAllowCredentials, "CORS_ALLOWED_ORIGINS", "cannot be * when CORS_ALLOW_CREDENTIALS is set")
+			continue
+		}
+		u, err := url.Parse(origin)
+		v.check(err == nil && u.Scheme != "" && u.Host != "" && u.Path == "",
+			"CORS_ALLOWED_ORIGINS", fmt.Sprintf("%q is not an origin such as https://example.com", origin))
+	}
+	v.check(c.CORSMaxAge >= 0, "CORS_MAX_AGE", "must not be negative")
+
+	if _, err := pgxpool.ParseConfig(c.PostgresConn); err != nil {
+		v.add("POSTGRES_CONN", "is invalid: "+err.Error())
+	}
+	v.check(c.PostgresMaxConns >= 1, "POSTGRES_MAX_CONNS", "must be at least 1")
+	v.check(c.PostgresMinConns >= 0 && c.PostgresMinConns <= c.PostgresMaxConns,
+		"POSTGRES_MIN_CONNS", "must be between 0 and POSTGRES_MAX_CONNS")
+	v.positive("POSTGRES_MAX_CONN_LIFETIME", c.PostgresMaxConnLifetime)
+	v.positive("POSTGRES_MAX_CONN_IDLE_TIME", c.PostgresMaxConnIdleTime)
+	v.positive("POSTGRES_HEALTH_CHECK_PERIOD", c.PostgresHealthCheckPeriod)
+	v.nonNegative("POSTGRES_STATEMENT_TIMEOUT", c.PostgresStatementTimeout)
+	v.nonNegative("POSTGRES_CONNECT_TIMEOUT", c.PostgresConnectTimeout)
+
+	v.oneOf("LOG_LEVEL", strings.ToLower(c.LogLevel), "debug", "info", "warn", "error")
+	v.oneOf("LOG_FORMAT", strings.ToLower(c.LogFormat), logging.FormatJSON, logging.FormatText)
+
+	v.oneOf("TRACING_EXPORTER", c.TracingExporter, tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout)
+	v.check(c.Profile != ProfileProd || c.TracingExporter != tracing.ExporterStdout,
+		"TRACING_EXPORTER", "stdout is meant for local use and not allowed in the prod profile")
+	v.check(c.TracingSampleRatio >= 0 && c.TracingSampleRatio <= 1, "TRACING_SAMPLE_RATIO", "must be between 0 and 1")
+
+	if c.RateLimitEnabled {
+		v.oneOf("RATE_LIMIT_STORE", c.RateLimitStore, ratelimit.StoreMemory, ratelimit.StorePostgres)
+		v.positive("RATE_LIMIT_WINDOW", c.RateLimitWindow)
+		v.check(c.RateLimitRead >= 0, "RATE_LIMIT_READ", "must not be negative")
+		v.check(c.RateLimitWrite >= 0, "RATE_LIMIT_WRITE", "must not be negative")
+		v.check(c.RateLimitSearch >= 0, "RATE_LIMIT_SEARCH", "must not be negative")
+		v.check(c.RateLimitAuth >= 0, "RATE_LIMIT_AUTH", "must not be negative")
+	}
+
+	// The service is required by the commands that look songs up only,
+	// see RequireMusicInfo.
+	if u, err := url.Parse(c.MusicInfoServiceURL); c.MusicInfoServiceURL != "" &&
+		(err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+		v.add("MUSIC_INFO_SERVICE_URL", "must be an http or https URL")
+	}
+	v.nonNegative("MUSIC_INFO_TIMEOUT", c.MusicInfoTimeout)
+	v.check(c.MusicInfoCacheSize >= 0, "MUSIC_INFO_CACHE_SIZE", "must not be negative")
+	v.check(c.MusicInfoBatchConcurrency >= 1, "MUSIC_INFO_BATCH_CONCURRENCY", "must be at least 1")
+	v.check(c.MusicInfoBatchSize >= 0, "MUSIC_INFO_BATCH_SIZE", "must not be negative")
+	v.check(c.MusicInfoBatchSize <= music_info_client.MaxBatchSize, "MUSIC_INFO_BATCH_SIZE",
+		fmt.Sprintf("must be at most %d", music_info_client.MaxBatchSize))
+	v.check(c.MusicInfoRateLimit >= 0, "MUSIC_INFO_RATE_LIMIT", "must not be negative")
+	v.check(c.MusicInfoMaxRetries >= 0, "MUSIC_INFO_MAX_RETRIES", "must not be negative")
+	v.check(c.MusicInfoHMACSecret == "" || c.MusicInfoHMACKeyID != "",
+		"MUSIC_INFO_HMAC_KEY_ID", "is required when MUSIC_INFO_HMAC_SECRET is set")
+
+	v.check(c.WorkerConcurrency >= 1, "WORKER_CONCURRENCY", "must be at least 1")
+	v.positive("WORKER_POLL_INTERVAL", c.WorkerPollInterval)
+	v.positive("WORKER_LOCK_TIMEOUT", c.WorkerLockTimeout)
+	v.check(c.JobMaxAttempts >= 1, "JOB_MAX_ATTEMPTS", "must be at least 1")
+	v.nonNegative("JOB_RETRY_BACKOFF", c.JobRetryBackoff)
+
+	if len(v.errs) > 0 {
+		return errors.Wrap(stderrors.Join(v.errs...), "invalid config")
+	}
+	return nil
+}
+
+// RequireMusicInfo reports a missing music info service. Migrations and key
+// management run without it, serving and refreshing songs do not.
+func (c *Config) RequireMusicInfo() error {
+	if c.MusicInfoServiceURL == "" {
+		return errors.New("invalid config: MUSIC_INFO_SERVICE_URL is required")
+	}
+	return nil
+}
+
+type validator struct {
+	errs []error
+}
+
+func (v *validator) add(key, message string) {
+	v.errs = append(v.errs, errors.Errorf("%s %s", key, message))
+}
+
+func (v *validator) check(ok bool, key, message string) {
+	if !ok {
+		v.add(key, message)
+	}
+}
+
+func (v *validator) positive(key string, d time.Duration) {
+	v.check(d > 0, key, "must be positive")
+}
+
+func (v *validator) nonNegative(key string, d time.Duration) {
+	v.check(d >= 0, key, "must not be negative")
+}
+
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	if slices.Contains(allowed, value) {
+		return
+	}
+
+	names := make([]string, 0, len(allowed))
+	for _, name := range allowed {
+		if name == "" {
+			name = "empty"
+		}
+		names = append(names, name)
+	}
+	v.add(key, fmt.Sprintf("is %q, must be one of %s", value, strings.Join(names, ", ")))
+}
@@ -0,0 +1,29 @@
+package converter
+
+import (
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"song-library-api/src/cmd/api/internal/model"
+)
+
+func ToRecordFromAPIKey(apiKey model.APIKey) goqu.Record {
+	record := goqu.Record{}
+
+	if apiKey.ID != uuid.Nil {
+		record["id"] = apiKey.ID
+	}
+	if apiKey.Name != "" {
+		record["name"] = apiKey.Name
+	}
+	if apiKey.Prefix != "" {
+		record["prefix"] = apiKey.Prefix
+	}
+	if apiKey.KeyHash != "" {
+		record["key_hash"] = apiKey.KeyHash
+	}
+	if apiKey.RevokedAt != nil {
+		record["revoked_at"] = *apiKey.RevokedAt
+	}
+
+	return record
+}
@@ -0,0 +1,32 @@
+package converter
+
+import (
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"song-library-api/src/cmd/api/internal/model"
+)
+
+func ToRecordFromJob(job model.Job) goqu.Record {
+	record := goqu.Record{}
+
+	if job.ID != uuid.Nil {
+		record["id"] = job.ID
+	}
+	if job.Type != "" {
+		record["type"] = job.Type
+	}
+	if len(job.Payload) != 0 {
+		record["payload"] = string(job.Payload)
+	}
+	if job.Status != "" {
+		record["status"] = job.Status
+	}
+	if job.MaxAttempts != 0 {
+		record["max_attempts"] = job.MaxAttempts
+	}
+	if !job.ScheduledAt.IsZero() {
+		record["scheduled_at"] = job.ScheduledAt
+	}
+
+	return record
+}
@@ -27,6 +27,12 @@ func ToRecordFromSong(song model.Song) goqu.Record {
 	if !song.ReleaseDate.IsZero() {
 		record["release_date"] = song.ReleaseDate
 	}
+	if song.ReleaseDatePrecision != "" {
+		record["release_date_precision"] = song.ReleaseDatePrecision
+	}
+	if song.Provenance != nil {
+		record["provenance"] = song.Provenance
+	}
 	if !song.CreatedAt.IsZero() {
 		record["created_at"] = song.CreatedAt
 	}
@@ -37,6 +43,14 @@ func ToRecordFromSong(song model.Song) goqu.Record {
 	return record
 }
 
+// ToUpdateRecordFromSong keeps empty text and link so that they can be cleared.
+func ToUpdateRecordFromSong(song model.Song) goqu.Record {
+	record := ToRecordFromSong(song)
+	record["text"] = song.Text
+	record["link"] = song.Link
+	return record
+}
+
 func ToViewFromSong(song model.Song) model.SongView {
 	return model.SongView{
 		ID:          song.ID,
@@ -57,3 +71,21 @@ func ToViewsFromSong(songs []model.Song) []model.SongView {
 	}
 	return views
 }
+
+func ToViewFromSongRefresh(refresh model.SongRefresh) model.SongRefreshView {
+	return model.SongRefreshView{
+		Song:    ToViewFromSong(refresh.Song),
+		Changes: refresh.Changes,
+		Skipped: refresh.Skipped,
+		DryRun:  refresh.DryRun,
+		Error:   refresh.Error,
+	}
+}
+
+func ToViewsFromSongRefresh(refreshes []model.SongRefresh) []model.SongRefreshView {
+	views := make([]model.SongRefreshView, 0, len(refreshes))
+	for _, refresh := range refreshes {
+		views = append(views, ToViewFromSongRefresh(refresh))
+	}
+	return views
+}
@@ -0,0 +1,37 @@
+package converter
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/pkg/partial_date"
+	"testing"
+	"time"
+)
+
+// TestToViewFromSong_v1 pins the v1 wire format, which existing clients rely on.
+func TestToViewFromSong_v1(t *testing.T) {
+	song := model.Song{
+		ID:         uuid.MustParse("6f1c2a9e-8f3a-4b7e-9a44-2f1d6c0e5b11"),
+		Group:      "Muse",
+		Song:       "Supermassive Black Hole",
+		Text:       "Verse",
+		Link:       "https://example.com",
+		CreatedAt:  time.Date(2025, time.January, 2, 10, 0, 0, 0, time.UTC),
+		UpdatedAt:  time.Date(2025, time.February, 3, 10, 0, 0, 0, time.UTC),
+		Provenance: model.SongProvenance{},
+	}
+	song.SetReleaseDate(partial_date.New(time.Date(2006, time.July, 1, 0, 0, 0, 0, time.UTC), partial_date.PrecisionMonth))
+	song.Provenance.SetManual(model.SongFieldText, time.Now())
+
+	got, err := json.Marshal(ToViewFromSong(song))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"ID":"6f1c2a9e-8f3a-4b7e-9a44-2f1d6c0e5b11","Group":"Muse","Song":"Supermassive Black Hole",` +
+		`"Text":"Verse","Link":"https://example.com","ReleaseDate":"01.07.2006","CreatedAt":"02.01.2025","UpdatedAt":"03.02.2025"}`
+	if string(got) != want {
+		t.Errorf("ToViewFromSong() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,131 @@
+package converter
+
+import (
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/cmd/api/internal/server/http/v2/view"
+)
+
+func ToV2ViewFromSong(song model.Song) view.Song {
+	result := view.Song{
+		ID: song.ID,
+		Group: view.Group{
+			ID:   song.GroupID,
+			Name: song.Group,
+		},
+		Song:       song.Song,
+		Text:       song.Text,
+		Link:       song.Link,
+		Provenance: song.Provenance,
+		CreatedAt:  song.CreatedAt,
+		UpdatedAt:  song.UpdatedAt,
+	}
+	if result.Provenance == nil {
+		result.Provenance = model.SongProvenance{}
+	}
+
+	if releaseDate := song.PartialReleaseDate(); !releaseDate.IsZero() {
+		iso := releaseDate.ISO()
+		result.ReleaseDate = &iso
+		result.ReleaseDatePrecision = releaseDate.Precision
+	}
+
+	return result
+}
+
+func ToV2ViewsFromSong(songs []model.Song) []view.Song {
+	views := make([]view.Song, 0, len(songs))
+	for _, song := range songs {
+		views = append(views, ToV2ViewFromSong(song))
+	}
+	return views
+}
+
+func ToV2ViewFromSongRefresh(refresh model.SongRefresh) view.SongRefresh {
+	changes := make([]view.SongFieldChange, 0, len(refresh.Changes))
+	for _, change := range refresh.Changes {
+		changes = append(changes, view.SongFieldChange{
+			Field: change.Field,
+			Old:   change.Old,
+			New:   change.New,
+		})
+	}
+
+	skipped := refresh.Skipped
+	if skipped == nil {
+		skipped = make([]string, 0)
+	}
+
+	return view.SongRefresh{
+		Song:    ToV2ViewFromSong(refresh.Song),
+		Changes: changes,
+		Skipped: skipped,
+		DryRun:  refresh.DryRun,
+		Error:   refresh.Error,
+	}
+}
+
+func ToV2ViewsFromSongRefresh(refreshes []model.SongRefresh) []view.SongRefresh {
+	views := make([]view.SongRefresh, 0, len(refreshes))
+	for _, refresh := range refreshes {
+		views = append(views, ToV2ViewFromSongRefresh(refresh))
+	}
+	return views
+}
+
+func ToV2ViewFromSongImport(result model.SongImport) view.SongImport {
+	errs := make([]view.SongImportError, 0, len(result.Errors))
+	for _, err := range result.Errors {
+		errs = append(errs, view.SongImportError{
+			Row:     err.Row,
+			Field:   err.Field,
+			Message: err.Message,
+		})
+	}
+
+	skips := make([]view.SongImportSkip, 0, len(result.SkippedRows))
+	for _, skip := range result.SkippedRows {
+		skips = append(skips, view.SongImportSkip{
+			Row:    skip.Row,
+			Group:  skip.Group,
+			Song:   skip.Song,
+			Reason: skip.Reason,
+		})
+	}
+
+	return view.SongImport{
+		Total:       result.Total,
+		Imported:    result.Imported,
+		Failed:      result.Failed,
+		Skipped:     result.Skipped,
+		DryRun:      result.DryRun,
+		Errors:      errs,
+		SkippedRows: skips,
+	}
+}
+
+func ToV2ViewFromSongList(list model.PaginatedList[model.Song]) view.SongList {
+	return view.SongList{
+		Items:      ToV2ViewsFromSong(list.Items),
+		Pagination: toV2Pagination(list),
+	}
+}
+
+func ToV2ViewFromSongText(list model.PaginatedList[string]) view.SongText {
+	verses := list.Items
+	if verses == nil {
+		verses = make([]string, 0)
+	}
+
+	return view.SongText{
+		Verses:     verses,
+		Pagination: toV2Pagination(list),
+	}
+}
+
+func toV2Pagination[T any](list model.PaginatedList[T]) view.Pagination {
+	return view.Pagination{
+		Page:       list.Page,
+		PageSize:   list.PageSize,
+		TotalPages: list.TotalPages,
+	}
+}
@@ -2,24 +2,185 @@ package postgres
 
 import (
 	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pkg/errors"
+	"strconv"
+	"time"
 )
 
-func NewDB(ctx context.Context, conn string) (*pgxpool.Pool, error) {
+const (
+	retryBackoff    = 250 * time.Millisecond
+	maxRetryBackoff = 5 * time.Second
+
+	codeQueryCanceled     = "57014"
+	codeCannotConnectNow  = "57P03"
+	statementTimeoutParam = "statement_timeout"
+)
+
+type options struct {
+	pool         *pgxpool.Config
+	retryTimeout time.Duration
+	onRetry      func(err error, wait time.Duration)
+}
+
+type Option func(o *options)
+
+// WithTracer hooks the tracer into every connection of the pool.
+func WithTracer(tracer pgx.QueryTracer) Option {
+	return func(o *options) {
+		o.pool.ConnConfig.Tracer = tracer
+	}
+}
+
+// WithPoolSize keeps between minConns and maxConns connections open.
+func WithPoolSize(maxConns, minConns int32) Option {
+	return func(o *options) {
+		o.pool.MaxConns = maxConns
+		o.pool.MinConns = minConns
+	}
+}
+
+// WithConnLifetime closes connections older than lifetime or idle for longer
+// than idleTime. The health check of idle connections runs every
+// healthCheckPeriod and also enforces both.
+func WithConnLifetime(lifetime, idleTime, healthCheckPeriod time.Duration) Option {
+	return func(o *options) {
+		o.pool.MaxConnLifetime = lifetime
+		o.pool.MaxConnIdleTime = idleTime
+		o.pool.HealthCheckPeriod = healthCheckPeriod
+	}
+}
+
+// WithStatementTimeout makes the server abort statements running longer than
+// timeout. Zero keeps the server default. Bulk statements lift it with
+// SetLocalStatementTimeout.
+func WithStatementTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		if timeout > 0 {
+			o.pool.ConnConfig.RuntimeParams[statementTimeoutParam] = strconv.FormatInt(timeout.Milliseconds(), 10)
+		}
+	}
+}
+
+// WithConnectRetry keeps trying to reach the database for up to timeout with
+// exponential backoff, as it may still be starting up. onRetry is called
+// before every wait and may be nil.
+func WithConnectRetry(timeout time.Duration, onRetry func(err error, wait time.Duration)) Option {
+	return func(o *options) {
+		o.retryTimeout = timeout
+		o.onRetry = onRetry
+	}
+}
+
+func NewDB(ctx context.Context, conn string, opts ...Option) (*pgxpool.Pool, error) {
 	cfg, err := pgxpool.ParseConfig(conn)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse config")
 	}
+	o := &options{pool: cfg}
+	for _, opt := range opts {
+		opt(o)
+	}
 
 	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
 		return nil, errors.Wrap(err, "init pool")
 	}
 
-	if err = pool.Ping(ctx); err != nil {
+	if err = ping(ctx, pool, o); err != nil {
+		pool.Close()
 		return nil, errors.Wrap(err, "ping pool")
 	}
 
 	return pool, nil
 }
+
+// WaitReady waits until the database accepts connections, for up to the
+// timeout given by WithConnectRetry. It is meant for clients that connect on
+// their own and give up on the first failure, such as the migrator.
+func WaitReady(ctx context.Context, conn string, opts ...Option) error {
+	cfg, err := pgxpool.ParseConfig(conn)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse config")
+	}
+	o := &options{pool: cfg}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	err = retry(ctx, o, func(ctx context.Context) error {
+		conn, err := pgx.ConnectConfig(ctx, cfg.ConnConfig)
+		if err != nil {
+			return err
+		}
+		return conn.Close(ctx)
+	})
+	return errors.Wrap(err, "wait for database")
+}
+
+func ping(ctx context.Context, pool *pgxpool.Pool, o *options) error {
+	return retry(ctx, o, pool.Ping)
+}
+
+func retry(ctx context.Context, o *options, attempt func(ctx context.Context) error) error {
+	deadline := time.Now().Add(o.retryTimeout)
+	wait := retryBackoff
+
+	for {
+		err := attempt(ctx)
+		if err == nil || ctx.Err() != nil || !retryable(err) || time.Now().Add(wait).After(deadline) {
+			return err
+		}
+
+		if o.onRetry != nil {
+			o.onRetry(err, wait)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, maxRetryBackoff)
+	}
+}
+
+// retryable tells a database that is not up yet from one that refuses the
+// connection, for example because of wrong credentials.
+func retryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == codeCannotConnectNow
+	}
+	return true
+}
+
+// SetLocalStatementTimeout replaces the statement timeout of the pool for the
+// rest of the transaction by the time left until the deadline of ctx, so
+// that bulk statements get the deadline of their route instead. Without a
+// deadline statements are not limited.
+func SetLocalStatementTimeout(ctx context.Context, tx interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}) error {
+	timeout := int64(0)
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = max(time.Until(deadline).Milliseconds(), 1)
+	}
+
+	if _, err := tx.Exec(ctx, "SET LOCAL "+statementTimeoutParam+" = "+strconv.FormatInt(timeout, 10)); err != nil {
+		return errors.Wrap(err, "failed to set statement timeout")
+	}
+	return nil
+}
+
+// IsTimeout reports whether a query was stopped by the deadline of its
+// context or by the statement timeout.
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == codeQueryCanceled
+}
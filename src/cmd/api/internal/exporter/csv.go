@@ -0,0 +1,76 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"github.com/pkg/errors"
+	"io"
+	"song-library-api/src/cmd/api/internal/model"
+	"time"
+)
+
+var csvHeader = []string{
+	"id",
+	model.SongFieldGroup,
+	model.SongFieldSong,
+	model.SongFieldText,
+	model.SongFieldLink,
+	model.SongFieldReleaseDate,
+	"createdAt",
+	"updatedAt",
+}
+
+type csvWriter struct {
+	writer        *csv.Writer
+	formatDate    DateFormat
+	headerWritten bool
+}
+
+func newCSVWriter(w io.Writer, formatDate DateFormat) *csvWriter {
+	return &csvWriter{writer: csv.NewWriter(w), formatDate: formatDate}
+}
+
+func (w *csvWriter) Write(song model.Song) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+
+	r := newRecord(song, w.formatDate)
+	err := w.writer.Write([]string{
+		r.ID.String(),
+		r.Group,
+		r.Song,
+		r.Text,
+		r.Link,
+		r.ReleaseDate,
+		r.CreatedAt.Format(time.RFC3339),
+		r.UpdatedAt.Format(time.RFC3339),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to write csv record")
+	}
+
+	return nil
+}
+
+func (w *csvWriter) Flush() error {
+	w.writer.Flush()
+	return errors.Wrap(w.writer.Error(), "failed to flush csv")
+}
+
+// Close writes the header even for an empty export and flushes the buffer.
+func (w *csvWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+func (w *csvWriter) writeHeader() error {
+	if w.headerWritten {
+		return nil
+	}
+	w.headerWritten = true
+
+	return errors.Wrap(w.writer.Write(csvHeader), "failed to write csv header")
+}
@@ -0,0 +1,59 @@
+package exporter
+
+import (
+	"bytes"
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/pkg/partial_date"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSVWriter_Flush(t *testing.T) {
+	var buf bytes.Buffer
+	writer := newCSVWriter(&buf, partial_date.Date.String)
+
+	if err := writer.Write(model.Song{Group: "Muse", Song: "Hysteria"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("written before Flush() = %q, want it buffered", buf.String())
+	}
+
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 2 || !strings.Contains(lines[1], "Muse,Hysteria") {
+		t.Errorf("written after Flush() = %q, want the header and the song", buf.String())
+	}
+}
+
+func TestCSVWriter_dateFormat(t *testing.T) {
+	song := model.Song{Group: "Muse", Song: "Hysteria"}
+	song.SetReleaseDate(partial_date.New(time.Date(2003, time.December, 1, 0, 0, 0, 0, time.UTC), partial_date.PrecisionMonth))
+
+	tests := []struct {
+		name       string
+		formatDate DateFormat
+		want       string
+	}{
+		{name: "v1", formatDate: partial_date.Date.String, want: ",12.2003,"},
+		{name: "v2", formatDate: partial_date.Date.ISO, want: ",2003-12,"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer := newCSVWriter(&buf, tt.formatDate)
+			if err := writer.Write(song); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("written = %q, want the release date as %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package exporter
+
+import (
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"io"
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/pkg/partial_date"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatJSON   Format = "json"
+	FormatNDJSON Format = "ndjson"
+)
+
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(strings.ToLower(value)); format {
+	case FormatCSV, FormatJSON, FormatNDJSON:
+		return format, nil
+	default:
+		return "", errors.Wrapf(model.ErrBadRequest, "unsupported export format %q", value)
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/json"
+	}
+}
+
+func (f Format) Filename(name string) string {
+	return name + "." + string(f)
+}
+
+// Writer encodes songs one at a time. Flush writes buffered songs to the
+// underlying writer. Close must be called to finish the document, it does not
+// close the underlying writer.
+type Writer interface {
+	Write(song model.Song) error
+	Flush() error
+	Close() error
+}
+
+// DateFormat renders the release date, e.g. partial_date.Date.String or
+// partial_date.Date.ISO.
+type DateFormat func(date partial_date.Date) string
+
+func NewWriter(w io.Writer, format Format, formatDate DateFormat) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, formatDate), nil
+	case FormatJSON:
+		return newJSONWriter(w, false, formatDate), nil
+	case FormatNDJSON:
+		return newJSONWriter(w, true, formatDate), nil
+	default:
+		return nil, errors.Wrapf(model.ErrBadRequest, "unsupported export format %q", format)
+	}
+}
+
+// record uses the import field names, so an export can be imported back.
+type record struct {
+	ID          uuid.UUID `json:"id"`
+	Group       string    `json:"group"`
+	Song        string    `json:"song"`
+	Text        string    `json:"text"`
+	Link        string    `json:"link"`
+	ReleaseDate string    `json:"releaseDate"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+func newRecord(song model.Song, formatDate DateFormat) record {
+	return record{
+		ID:          song.ID,
+		Group:       song.Group,
+		Song:        song.Song,
+		Text:        song.Text,
+		Link:        song.Link,
+		ReleaseDate: formatDate(song.PartialReleaseDate()),
+		CreatedAt:   song.CreatedAt,
+		UpdatedAt:   song.UpdatedAt,
+	}
+}
@@ -0,0 +1,60 @@
+package exporter
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"song-library-api/src/cmd/api/internal/model"
+)
+
+// jsonWriter writes either a JSON array or one object per line.
+type jsonWriter struct {
+	w          io.Writer
+	encoder    *json.Encoder
+	delimited  bool
+	formatDate DateFormat
+	count      int
+}
+
+func newJSONWriter(w io.Writer, delimited bool, formatDate DateFormat) *jsonWriter {
+	return &jsonWriter{
+		w:          w,
+		encoder:    json.NewEncoder(w),
+		delimited:  delimited,
+		formatDate: formatDate,
+	}
+}
+
+func (w *jsonWriter) Write(song model.Song) error {
+	if !w.delimited {
+		separator := ","
+		if w.count == 0 {
+			separator = "["
+		}
+		if _, err := io.WriteString(w.w, separator); err != nil {
+			return errors.Wrap(err, "failed to write json")
+		}
+	}
+	w.count++
+
+	// Encode terminates every value with a newline.
+	return errors.Wrap(w.encoder.Encode(newRecord(song, w.formatDate)), "failed to write json")
+}
+
+// Flush has nothing to do, songs are encoded straight to the underlying writer.
+func (w *jsonWriter) Flush() error {
+	return nil
+}
+
+func (w *jsonWriter) Close() error {
+	if w.delimited {
+		return nil
+	}
+
+	end := "]\n"
+	if w.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(w.w, end)
+	return errors.Wrap(err, "failed to write json")
+}
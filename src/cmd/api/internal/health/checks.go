@@ -0,0 +1,40 @@
+package health
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+func PostgresCheck(pool *pgxpool.Pool) Check {
+	return func(ctx context.Context) error {
+		return pool.Ping(ctx)
+	}
+}
+
+// MigrationCheck fails on a dirty migration state and when migrations up to
+// the expected version are not applied yet. A newer schema is accepted, so
+// that the previous release stays ready during a rollout.
+func MigrationCheck(pool *pgxpool.Pool, expected uint) Check {
+	return func(ctx context.Context) error {
+		var version uint
+		var dirty bool
+		err := pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errors.New("no migration applied")
+			}
+			return errors.Wrap(err, "failed to get migration version")
+		}
+
+		switch {
+		case dirty:
+			return errors.Errorf("migration %d is dirty", version)
+		case version < expected:
+			return errors.Errorf("migration version %d is behind %d", version, expected)
+		}
+
+		return nil
+	}
+}
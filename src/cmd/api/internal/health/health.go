@@ -0,0 +1,112 @@
+package health
+
+import (
+	"context"
+	"song-library-api/src/cmd/api/internal/tracing"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK           = "ok"
+	StatusFail         = "fail"
+	StatusShuttingDown = "shutting down"
+)
+
+// Check returns an error if the dependency is not usable.
+type Check func(ctx context.Context) error
+
+type CheckResult struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the readiness checks of the dependencies concurrently, each
+// bounded by the timeout.
+type Checker struct {
+	checks       []namedCheck
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+func (c *Checker) Register(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// SetShuttingDown makes the service report not ready, so that the load
+// balancer stops routing traffic to it before the server is drained.
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+func (c *Checker) Ready(ctx context.Context) Report {
+	if c.shuttingDown.Load() {
+		return Report{Status: StatusShuttingDown, Checks: make([]CheckResult, 0)}
+	}
+
+	// Probes come every few seconds, their checks are not traced.
+	ctx = tracing.Suppress(ctx)
+
+	results := make([]CheckResult, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	status := StatusOK
+	for _, result := range results {
+		if result.Status != StatusOK {
+			status = StatusFail
+		}
+	}
+
+	return Report{Status: status, Checks: results}
+}
+
+func (c *Checker) run(ctx context.Context, check namedCheck) CheckResult {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := check.check(ctx)
+	result := CheckResult{
+		Name:    check.name,
+		Status:  StatusOK,
+		Latency: time.Since(start).String(),
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	return result
+}
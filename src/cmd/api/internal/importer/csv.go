@@ -0,0 +1,58 @@
+package importer
+
+import (
+	"encoding/csv"
+	"github.com/pkg/errors"
+	"io"
+	"song-library-api/src/cmd/api/internal/model"
+	"strings"
+)
+
+// readCSV expects a header row with the column names.
+func readCSV(r io.Reader, mapping Mapping) ([]model.SongImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.Wrap(model.ErrBadRequest, "csv header is missing")
+		}
+		return nil, errors.Wrap(model.ErrBadRequest, err.Error())
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheet exports often start with a byte order mark.
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, field := range []string{model.SongFieldGroup, model.SongFieldSong} {
+		if _, ok := columns[mapping.source(field)]; !ok {
+			return nil, errors.Wrapf(model.ErrBadRequest, "csv column %q is missing", mapping.source(field))
+		}
+	}
+
+	rows := make([]model.SongImportRow, 0)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(model.ErrBadRequest, err.Error())
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, newRow(line, mapping, func(source string) string {
+			i, ok := columns[source]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return record[i]
+		}))
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,102 @@
+package importer
+
+import (
+	"github.com/pkg/errors"
+	"io"
+	"path/filepath"
+	"song-library-api/src/cmd/api/internal/model"
+	"strings"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatJSON   Format = "json"
+	FormatNDJSON Format = "ndjson"
+)
+
+var fields = []string{
+	model.SongFieldGroup,
+	model.SongFieldSong,
+	model.SongFieldText,
+	model.SongFieldLink,
+	model.SongFieldReleaseDate,
+}
+
+// Mapping maps song fields to the column or key names used in the file.
+// Fields that are not mapped are looked up by their own name. Names are
+// matched case-insensitively.
+type Mapping map[string]string
+
+func (m Mapping) Validate() error {
+	for field := range m {
+		if !isField(field) {
+			return errors.Wrapf(model.ErrBadRequest, "unknown mapping field %q", field)
+		}
+	}
+	return nil
+}
+
+func (m Mapping) source(field string) string {
+	if source, ok := m[field]; ok && source != "" {
+		return strings.ToLower(source)
+	}
+	return strings.ToLower(field)
+}
+
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(strings.ToLower(value)); format {
+	case FormatCSV, FormatJSON, FormatNDJSON:
+		return format, nil
+	case "jsonl":
+		return FormatNDJSON, nil
+	default:
+		return "", errors.Wrapf(model.ErrBadRequest, "unsupported import format %q", value)
+	}
+}
+
+// FormatFromFilename detects the format by the file extension.
+func FormatFromFilename(filename string) (Format, error) {
+	return ParseFormat(strings.TrimPrefix(filepath.Ext(filename), "."))
+}
+
+// Read decodes all songs from r. Malformed files are reported as
+// model.ErrBadRequest, invalid values are left to the import itself.
+func Read(r io.Reader, format Format, mapping Mapping) ([]model.SongImportRow, error) {
+	if err := mapping.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatCSV:
+		return readCSV(r, mapping)
+	case FormatJSON:
+		return readJSON(r, mapping)
+	case FormatNDJSON:
+		return readNDJSON(r, mapping)
+	default:
+		return nil, errors.Wrapf(model.ErrBadRequest, "unsupported import format %q", format)
+	}
+}
+
+func isField(field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// newRow builds a row from a lookup of lower-cased source names.
+func newRow(row int, mapping Mapping, value func(source string) string) model.SongImportRow {
+	return model.SongImportRow{
+		Row:         row,
+		Group:       strings.TrimSpace(value(mapping.source(model.SongFieldGroup))),
+		Song:        strings.TrimSpace(value(mapping.source(model.SongFieldSong))),
+		Text:        value(mapping.source(model.SongFieldText)),
+		Link:        strings.TrimSpace(value(mapping.source(model.SongFieldLink))),
+		ReleaseDate: strings.TrimSpace(value(mapping.source(model.SongFieldReleaseDate))),
+	}
+}
@@ -0,0 +1,138 @@
+package importer
+
+import (
+	"github.com/pkg/errors"
+	"reflect"
+	"song-library-api/src/cmd/api/internal/model"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  Format
+		mapping Mapping
+		input   string
+		want    []model.SongImportRow
+	}{
+		{
+			name:   "csv",
+			format: FormatCSV,
+			input:  "\ufeffGroup, Song, Text, releaseDate\nMuse, Hysteria ,\"Line one\nLine two\", 2003\nQueen,Bohemian Rhapsody\n",
+			want: []model.SongImportRow{
+				{Row: 2, Group: "Muse", Song: "Hysteria", Text: "Line one\nLine two", ReleaseDate: "2003"},
+				{Row: 4, Group: "Queen", Song: "Bohemian Rhapsody"},
+			},
+		},
+		{
+			name:    "csv with mapping",
+			format:  FormatCSV,
+			mapping: Mapping{model.SongFieldGroup: "Artist", model.SongFieldSong: "Title"},
+			input:   "artist,title,link\nMuse,Hysteria,https://example.com\n",
+			want: []model.SongImportRow{
+				{Row: 2, Group: "Muse", Song: "Hysteria", Link: "https://example.com"},
+			},
+		},
+		{
+			name:   "json",
+			format: FormatJSON,
+			input:  `[{"group": "Muse", "song": "Hysteria", "releaseDate": 2003, "extra": {"a": 1}}, {"Group": "Queen", "Song": "Bohemian Rhapsody", "link": null}]`,
+			want: []model.SongImportRow{
+				{Row: 1, Group: "Muse", Song: "Hysteria", ReleaseDate: "2003"},
+				{Row: 2, Group: "Queen", Song: "Bohemian Rhapsody"},
+			},
+		},
+		{
+			name:   "empty json",
+			format: FormatJSON,
+			input:  `[]`,
+			want:   []model.SongImportRow{},
+		},
+		{
+			name:    "ndjson with mapping",
+			format:  FormatNDJSON,
+			mapping: Mapping{model.SongFieldText: "lyrics"},
+			input:   "{\"group\": \"Muse\", \"song\": \"Hysteria\", \"Lyrics\": \"  la la\"}\n\n{\"group\": \"Queen\", \"song\": \"Bohemian Rhapsody\"}\n",
+			want: []model.SongImportRow{
+				{Row: 1, Group: "Muse", Song: "Hysteria", Text: "  la la"},
+				{Row: 3, Group: "Queen", Song: "Bohemian Rhapsody"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(strings.NewReader(tt.input), tt.format, tt.mapping)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Read() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  Format
+		mapping Mapping
+		input   string
+		wantErr string
+	}{
+		{name: "empty csv", format: FormatCSV, input: "", wantErr: "csv header is missing"},
+		{name: "csv without song column", format: FormatCSV, input: "group,text\nMuse,la\n", wantErr: `csv column "song" is missing`},
+		{name: "csv with unterminated quote", format: FormatCSV, input: "group,song\nMuse,\"Hysteria\n", wantErr: "extraneous or missing"},
+		{name: "json object", format: FormatJSON, input: `{"group": "Muse"}`, wantErr: "must be an array of objects"},
+		{name: "json array of strings", format: FormatJSON, input: `["Muse"]`, wantErr: "row 1"},
+		{name: "unterminated json", format: FormatJSON, input: `[{"group": "Muse", "song": "Hysteria"}`, wantErr: "unexpected end of JSON input"},
+		{name: "json value of mapped field", format: FormatJSON, input: `[{"group": ["Muse"], "song": "Hysteria"}]`, wantErr: `row 1: "group" must be a string`},
+		{name: "malformed ndjson line", format: FormatNDJSON, input: "{\"group\": \"Muse\", \"song\": \"Hysteria\"}\n{\"group\"\n", wantErr: "row 2"},
+		{name: "unknown mapping field", format: FormatCSV, mapping: Mapping{"artist": "group"}, input: "group,song\n", wantErr: `unknown mapping field "artist"`},
+		{name: "unknown format", format: "xml", input: "", wantErr: `unsupported import format "xml"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.input), tt.format, tt.mapping)
+			if !errors.Is(err, model.ErrBadRequest) {
+				t.Fatalf("Read() error = %v, want a bad request", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Read() error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     Format
+		wantErr  bool
+	}{
+		{filename: "songs.csv", want: FormatCSV},
+		{filename: "songs.JSON", want: FormatJSON},
+		{filename: "songs.ndjson", want: FormatNDJSON},
+		{filename: "songs.jsonl", want: FormatNDJSON},
+		{filename: "songs.xlsx", wantErr: true},
+		{filename: "songs", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			got, err := FormatFromFilename(tt.filename)
+			if tt.wantErr {
+				if !errors.Is(err, model.ErrBadRequest) {
+					t.Errorf("FormatFromFilename() error = %v, want a bad request", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("FormatFromFilename() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"song-library-api/src/cmd/api/internal/model"
+	"strings"
+)
+
+// readJSON expects an array of objects and decodes it element by element.
+func readJSON(r io.Reader, mapping Mapping) ([]model.SongImportRow, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, errors.Wrap(model.ErrBadRequest, err.Error())
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, errors.Wrap(model.ErrBadRequest, "json import must be an array of objects")
+	}
+
+	rows := make([]model.SongImportRow, 0)
+	for i := 1; decoder.More(); i++ {
+		var object map[string]any
+		if err = decoder.Decode(&object); err != nil {
+			return nil, errors.Wrapf(model.ErrBadRequest, "row %d: %s", i, err)
+		}
+
+		row, err := newRowFromObject(i, mapping, object)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+
+	if _, err = decoder.Token(); err != nil {
+		return nil, errors.Wrap(model.ErrBadRequest, err.Error())
+	}
+
+	return rows, nil
+}
+
+// readNDJSON expects one object per line and skips blank lines.
+func readNDJSON(r io.Reader, mapping Mapping) ([]model.SongImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	// Lyrics make lines much longer than the default token size.
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	rows := make([]model.SongImportRow, 0)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+
+		var object map[string]any
+		if err := decoder.Decode(&object); err != nil {
+			return nil, errors.Wrapf(model.ErrBadRequest, "row %d: %s", line, err)
+		}
+
+		row, err := newRowFromObject(line, mapping, object)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(model.ErrBadRequest, err.Error())
+	}
+
+	return rows, nil
+}
+
+func newRowFromObject(i int, mapping Mapping, object map[string]any) (model.SongImportRow, error) {
+	values := make(map[string]string, len(object))
+	for key, value := range object {
+		switch v := value.(type) {
+		case nil:
+		case string:
+			values[strings.ToLower(key)] = v
+		case json.Number:
+			// Release years are often written as numbers.
+			values[strings.ToLower(key)] = v.String()
+		case bool:
+			values[strings.ToLower(key)] = fmt.Sprint(v)
+		default:
+			if isMapped(mapping, key) {
+				return model.SongImportRow{}, errors.Wrapf(model.ErrBadRequest, "row %d: %q must be a string", i, key)
+			}
+		}
+	}
+
+	return newRow(i, mapping, func(source string) string {
+		return values[source]
+	}), nil
+}
+
+func isMapped(mapping Mapping, key string) bool {
+	key = strings.ToLower(key)
+	for _, field := range fields {
+		if mapping.source(field) == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+type attrsKey struct{}
+
+// With returns a context carrying the given attributes, in the same
+// key-value form as slog.Logger.With. They are added to every record logged
+// with the context.
+func With(ctx context.Context, args ...any) context.Context {
+	attrs := attrsFromContext(ctx)
+	record := slog.Record{}
+	record.Add(args...)
+
+	merged := make([]slog.Attr, 0, len(attrs)+record.NumAttrs())
+	merged = append(merged, attrs...)
+	record.Attrs(func(attr slog.Attr) bool {
+		merged = append(merged, attr)
+		return true
+	})
+
+	return context.WithValue(ctx, attrsKey{}, merged)
+}
+
+func attrsFromContext(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+var _ slog.Handler = (*ContextHandler)(nil)
+
+// ContextHandler adds the attributes stored by With to the records logged
+// with one of the *Context methods of slog.Logger.
+type ContextHandler struct {
+	slog.Handler
+}
+
+func NewContextHandler(handler slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: handler}
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	record.AddAttrs(attrsFromContext(ctx)...)
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return NewContextHandler(h.Handler.WithAttrs(attrs))
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return NewContextHandler(h.Handler.WithGroup(name))
+}
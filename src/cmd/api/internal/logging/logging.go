@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"github.com/pkg/errors"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys whose values are never written.
+var sensitiveKeys = map[string]struct{}{
+	"apikey":        {},
+	"authorization": {},
+	"password":      {},
+	"secret":        {},
+	"token":         {},
+}
+
+// NewHandler creates a handler writing records of at least the given level
+// (debug, info, warn or error) in the given format.
+func NewHandler(w io.Writer, level, format string) (slog.Handler, error) {
+	var minLevel slog.Level
+	if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, errors.Errorf("unknown log level %q", level)
+	}
+
+	opts := &slog.HandlerOptions{
+		Level:       minLevel,
+		ReplaceAttr: redact,
+	}
+
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		return slog.NewJSONHandler(w, opts), nil
+	case FormatText:
+		return slog.NewTextHandler(w, opts), nil
+	default:
+		return nil, errors.Errorf("unknown log format %q", format)
+	}
+}
+
+func redact(_ []string, attr slog.Attr) slog.Attr {
+	if _, ok := sensitiveKeys[strings.ToLower(attr.Key)]; ok {
+		return slog.String(attr.Key, redacted)
+	}
+	return attr
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Middleware records requests by route template rather than by URL, so that
+// IDs in paths do not create new series.
+func (m *Metrics) Middleware(skipper func(c echo.Context) bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if skipper != nil && skipper(c) {
+				return next(c)
+			}
+
+			start := time.Now()
+			err := next(c)
+			elapsed := time.Since(start)
+
+			status := c.Response().Status
+			if err != nil && !c.Response().Committed {
+				status = http.StatusInternalServerError
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					status = httpErr.Code
+				}
+			}
+
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+
+			labels := []string{c.Request().Method, route, strconv.Itoa(status)}
+			m.httpRequests.WithLabelValues(labels...).Inc()
+			m.httpDuration.WithLabelValues(labels...).Observe(elapsed.Seconds())
+
+			return err
+		}
+	}
+}
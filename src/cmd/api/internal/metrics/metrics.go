@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+const namespace = "song_library"
+
+// Metrics owns the collectors of the service. They are registered on a
+// dedicated registry rather than the global one.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	queryDuration *prometheus.HistogramVec
+	queryErrors   *prometheus.CounterVec
+
+	musicInfoDuration *prometheus.HistogramVec
+	musicInfoErrors   *prometheus.CounterVec
+
+	songsCreated  prometheus.Counter
+	groupsCreated prometheus.Counter
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests by route and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request duration by route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "query_duration_seconds",
+			Help:      "Duration of repository methods.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"repository", "method"}),
+		queryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "query_errors_total",
+			Help:      "Failed repository methods, not found results are not counted.",
+		}, []string{"repository", "method"}),
+		musicInfoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "music_info",
+			Name:      "request_duration_seconds",
+			Help:      "Music info service round trip duration by path and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "path", "status"}),
+		musicInfoErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "music_info",
+			Name:      "request_errors_total",
+			Help:      "Music info service requests that got no response.",
+		}, []string{"method", "path"}),
+		songsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "songs_created_total",
+			Help:      "Songs added one by one or by import.",
+		}),
+		groupsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "groups_created_total",
+			Help:      "Groups created for new songs.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.queryDuration,
+		m.queryErrors,
+		m.musicInfoDuration,
+		m.musicInfoErrors,
+		m.songsCreated,
+		m.groupsCreated,
+	)
+
+	return m
+}
+
+func (m *Metrics) Register(collector prometheus.Collector) {
+	m.registry.MustRegister(collector)
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+func (m *Metrics) SongsCreated(count int) {
+	m.songsCreated.Add(float64(count))
+}
+
+func (m *Metrics) GroupsCreated(count int) {
+	m.groupsCreated.Add(float64(count))
+}
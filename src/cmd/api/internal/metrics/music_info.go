@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"net/http"
+	"song-library-api/src/pkg/music_info_client"
+	"strconv"
+	"time"
+)
+
+// ObserveMusicInfoResponse is a music_info_client.ResponseHook.
+func (m *Metrics) ObserveMusicInfoResponse(request *http.Request,
+	response *http.Response,
+	err error,
+	elapsed time.Duration) {
+	if err != nil {
+		m.musicInfoErrors.WithLabelValues(request.Method, request.URL.Path).Inc()
+		return
+	}
+
+	m.musicInfoDuration.
+		WithLabelValues(request.Method, request.URL.Path, strconv.Itoa(response.StatusCode)).
+		Observe(elapsed.Seconds())
+}
+
+// musicInfoStatsCollector exposes the cumulative counters of the client.
+type musicInfoStatsCollector struct {
+	client *music_info_client.MusicInfoClient
+
+	requests             *prometheus.Desc
+	retries              *prometheus.Desc
+	rateLimitedResponses *prometheus.Desc
+	throttledWaits       *prometheus.Desc
+	throttledWaitTime    *prometheus.Desc
+}
+
+func NewMusicInfoStatsCollector(client *music_info_client.MusicInfoClient) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "music_info", name), help, nil, nil)
+	}
+
+	return &musicInfoStatsCollector{
+		client:               client,
+		requests:             desc("requests_total", "Requests sent, including retries."),
+		retries:              desc("retries_total", "Requests retried after a 429 response."),
+		rateLimitedResponses: desc("rate_limited_responses_total", "429 responses."),
+		throttledWaits:       desc("throttled_waits_total", "Requests delayed by the client rate limit."),
+		throttledWaitTime:    desc("throttled_wait_seconds_total", "Time spent waiting for the client rate limit."),
+	}
+}
+
+func (c *musicInfoStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.requests
+	ch <- c.retries
+	ch <- c.rateLimitedResponses
+	ch <- c.throttledWaits
+	ch <- c.throttledWaitTime
+}
+
+func (c *musicInfoStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.Stats()
+	ch <- prometheus.MustNewConstMetric(c.requests, prometheus.CounterValue, float64(stats.Requests))
+	ch <- prometheus.MustNewConstMetric(c.retries, prometheus.CounterValue, float64(stats.Retries))
+	ch <- prometheus.MustNewConstMetric(c.rateLimitedResponses, prometheus.CounterValue, float64(stats.RateLimitedResponses))
+	ch <- prometheus.MustNewConstMetric(c.throttledWaits, prometheus.CounterValue, float64(stats.ThrottledWaits))
+	ch <- prometheus.MustNewConstMetric(c.throttledWaitTime, prometheus.CounterValue, stats.ThrottledWaitTime.Seconds())
+}
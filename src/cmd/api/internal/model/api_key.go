@@ -0,0 +1,33 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+// APIKey identifies a client. Only the hash of the key is stored, the key
+// itself is shown once when it is created.
+type APIKey struct {
+	ID        uuid.UUID
+	Name      string
+	Prefix    string
+	KeyHash   string
+	RevokedAt *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// CreatedAPIKey is a new API key together with its plain value.
+type CreatedAPIKey struct {
+	APIKey APIKey
+	Key    string
+}
+
+// LogValue omits the plain key.
+func (k CreatedAPIKey) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", k.APIKey.ID.String()),
+		slog.String("name", k.APIKey.Name),
+		slog.String("prefix", k.APIKey.Prefix))
+}
@@ -5,8 +5,37 @@ import "github.com/pkg/errors"
 var (
 	ErrBadRequest = errors.New("bad request")
 	ErrNotFound   = errors.New("not found")
+	// ErrUnauthorized is returned for unknown or revoked API keys.
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrTooManyRequests = errors.New("too many requests")
+	// ErrJobLockLost is returned when a job was claimed again by another
+	// worker after its lock timed out.
+	ErrJobLockLost = errors.New("job lock lost")
 )
 
-type APIError struct {
+const (
+	// ProblemTypeBlank means the status code says all there is to know.
+	ProblemTypeBlank = "about:blank"
+	// ProblemTypeValidation lists the invalid fields in Errors.
+	ProblemTypeValidation = "urn:song-library:problem:validation"
+)
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	// Message repeats Detail for clients reading the former error body.
+	Message string `json:"message"`
+}
+
+// FieldError is a request field failing a validation rule, such as
+// required or max.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
 	Message string `json:"message"`
 }
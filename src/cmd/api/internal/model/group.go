@@ -11,3 +11,10 @@ type Group struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+type GroupMerge struct {
+	Group        Group
+	Merged       []string
+	MovedSongs   int64
+	SkippedSongs []string
+}
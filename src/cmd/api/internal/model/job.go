@@ -0,0 +1,36 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+type JobStatus string
+
+const (
+	JobStatusPending JobStatus = "pending"
+	JobStatusRunning JobStatus = "running"
+	JobStatusDone    JobStatus = "done"
+	JobStatusDead    JobStatus = "dead"
+)
+
+const JobTypeSongEnrich = "song.enrich"
+
+type Job struct {
+	ID          uuid.UUID
+	Type        string
+	Payload     json.RawMessage
+	Status      JobStatus
+	Attempts    int
+	MaxAttempts int
+	LastError   string
+	ScheduledAt time.Time
+	LockedAt    *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type SongEnrichPayload struct {
+	SongID uuid.UUID `json:"songId"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import "encoding/json"
+
+// Optional distinguishes a field missing from a PATCH body from an explicit null.
+type Optional[T any] struct {
+	Value T
+	Set   bool
+	Null  bool
+}
+
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{Value: value, Set: true}
+}
+
+func Null[T any]() Optional[T] {
+	return Optional[T]{Set: true, Null: true}
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+type FieldSource string
+
+const (
+	FieldSourceUpstream FieldSource = "upstream"
+	FieldSourceManual   FieldSource = "manual"
+)
+
+const ProviderMusicInfo = "music-info"
+
+const (
+	SongFieldGroup       = "group"
+	SongFieldSong        = "song"
+	SongFieldText        = "text"
+	SongFieldLink        = "link"
+	SongFieldReleaseDate = "releaseDate"
+)
+
+type FieldProvenance struct {
+	Source    FieldSource `json:"source"`
+	Provider  string      `json:"provider,omitempty"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+}
+
+// SongProvenance tracks where each song field value came from, keyed by field name.
+type SongProvenance map[string]FieldProvenance
+
+func (p SongProvenance) IsManual(field string) bool {
+	return p[field].Source == FieldSourceManual
+}
+
+func (p SongProvenance) SetManual(field string, at time.Time) {
+	p[field] = FieldProvenance{Source: FieldSourceManual, UpdatedAt: at}
+}
+
+func (p SongProvenance) SetUpstream(field, provider string, at time.Time) {
+	p[field] = FieldProvenance{Source: FieldSourceUpstream, Provider: provider, UpdatedAt: at}
+}
+
+func (p SongProvenance) Clone() SongProvenance {
+	clone := make(SongProvenance, len(p))
+	for field, provenance := range p {
+		clone[field] = provenance
+	}
+	return clone
+}
+
+func (p SongProvenance) Value() (driver.Value, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
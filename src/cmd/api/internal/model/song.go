@@ -2,6 +2,8 @@ package model
 
 import (
 	"github.com/google/uuid"
+	"log/slog"
+	"song-library-api/src/pkg/partial_date"
 	"time"
 )
 
@@ -13,8 +15,31 @@ type Song struct {
 	Text        string
 	Link        string
 	ReleaseDate time.Time
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	// ReleaseDatePrecision tells which components of ReleaseDate are known.
+	ReleaseDatePrecision partial_date.Precision
+	Provenance           SongProvenance
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+// LogValue keeps lyrics out of the logs, only their length is written.
+func (s Song) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", s.ID.String()),
+		slog.String("group", s.Group),
+		slog.String("song", s.Song),
+		slog.Int("textLength", len(s.Text)),
+		slog.String("link", s.Link),
+		slog.String("releaseDate", s.PartialReleaseDate().String()))
+}
+
+func (s *Song) PartialReleaseDate() partial_date.Date {
+	return partial_date.New(s.ReleaseDate, s.ReleaseDatePrecision)
+}
+
+func (s *Song) SetReleaseDate(date partial_date.Date) {
+	s.ReleaseDate = date.Time
+	s.ReleaseDatePrecision = date.Precision
 }
 
 type SongView struct {
@@ -33,5 +58,68 @@ type SongFilter struct {
 	Song        *string
 	Text        *string
 	Link        *string
-	ReleaseDate *time.Time
+	ReleaseDate *partial_date.Date
+}
+
+// LogValue dereferences the optional filters and hides the searched text.
+func (f *SongFilter) LogValue() slog.Value {
+	if f == nil {
+		return slog.GroupValue()
+	}
+
+	attrs := []slog.Attr{slog.String("groupId", f.GroupID.String())}
+	if f.Song != nil {
+		attrs = append(attrs, slog.String("song", *f.Song))
+	}
+	if f.Text != nil {
+		attrs = append(attrs, slog.Int("textLength", len(*f.Text)))
+	}
+	if f.Link != nil {
+		attrs = append(attrs, slog.String("link", *f.Link))
+	}
+	if f.ReleaseDate != nil {
+		attrs = append(attrs, slog.String("releaseDate", f.ReleaseDate.String()))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+type SongFieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+type SongRefreshOptions struct {
+	DryRun bool
+	// Force overwrites fields that were manually edited.
+	Force bool
+}
+
+type SongRefresh struct {
+	Song    Song
+	Changes []SongFieldChange
+	Skipped []string
+	DryRun  bool
+	Error   string
+}
+
+type SongRefreshView struct {
+	Song    SongView
+	Changes []SongFieldChange
+	Skipped []string
+	DryRun  bool
+	Error   string `json:",omitempty"`
+}
+
+// SongPatch describes a partial update. A null text, link or release date
+// resets the field to the upstream value, an empty text or link clears it.
+type SongPatch struct {
+	ID          uuid.UUID
+	Group       Optional[string]
+	Song        Optional[string]
+	Text        Optional[string]
+	Link        Optional[string]
+	ReleaseDate Optional[partial_date.Date]
+	// Refresh forces the upstream lookup even if the song identity is unchanged.
+	Refresh bool
 }
@@ -0,0 +1,50 @@
+package model
+
+// SongImportRow is a song read from an import file. Empty values are treated
+// as missing.
+type SongImportRow struct {
+	// Row is the line of the record in CSV and NDJSON files and its
+	// position in a JSON array, starting from 1.
+	Row         int
+	Group       string
+	Song        string
+	Text        string
+	Link        string
+	ReleaseDate string
+}
+
+type SongImportOptions struct {
+	// DryRun only validates rows without saving them.
+	DryRun bool
+	// Enrich fills missing text, link and release date from the music info service.
+	Enrich bool
+	// ChunkSize is the number of rows saved in one transaction.
+	ChunkSize int
+}
+
+type SongImportError struct {
+	Row     int
+	Field   string `json:",omitempty"`
+	Message string
+}
+
+// SongImportSkip is a valid row that was not saved because the song is in
+// the library or earlier in the file already.
+type SongImportSkip struct {
+	Row    int
+	Group  string
+	Song   string
+	Reason string
+}
+
+type SongImport struct {
+	Total    int
+	Imported int
+	Failed   int
+	Skipped  int
+	DryRun   bool
+	Errors   []SongImportError
+	// SkippedRows lists the songs that exist already. A dry run only finds
+	// the songs repeated in the file.
+	SkippedRows []SongImportSkip
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore keeps the counters of a single instance.
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	lastSweep time.Time
+}
+
+type counter struct {
+	windowStart time.Time
+	expiresAt   time.Time
+	hits        int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters: make(map[string]*counter),
+	}
+}
+
+func (s *MemoryStore) Hit(_ context.Context, key string, windowStart time.Time, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(windowStart)
+
+	c, ok := s.counters[key]
+	if !ok || !c.windowStart.Equal(windowStart) {
+		c = &counter{windowStart: windowStart, expiresAt: windowStart.Add(window)}
+		s.counters[key] = c
+	}
+	c.hits++
+
+	return c.hits, nil
+}
+
+// sweep drops the counters of ended windows at most once a minute.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, c := range s.counters {
+		if !now.Before(c.expiresAt) {
+			delete(s.counters, key)
+		}
+	}
+}
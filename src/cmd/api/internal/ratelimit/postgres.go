@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"sync/atomic"
+	"time"
+)
+
+var _ Store = (*PostgresStore)(nil)
+
+// PostgresStore shares the counters between instances.
+type PostgresStore struct {
+	pool      *pgxpool.Pool
+	table     string
+	lastSweep atomic.Int64
+}
+
+func NewPostgresStore(pool *pgxpool.Pool, table string) *PostgresStore {
+	return &PostgresStore{
+		pool:  pool,
+		table: table,
+	}
+}
+
+func (s *PostgresStore) Hit(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int, error) {
+	s.sweep(ctx, windowStart)
+
+	// A hit in a new window restarts the count.
+	query := goqu.Dialect("postgres").
+		Insert(s.table).
+		Rows(goqu.Record{
+			"key":          key,
+			"window_start": windowStart,
+			"hits":         1,
+			"expires_at":   windowStart.Add(window),
+		}).
+		OnConflict(goqu.DoUpdate("key", goqu.Record{
+			"hits": goqu.L("CASE WHEN ?.window_start = EXCLUDED.window_start THEN ?.hits + 1 ELSE 1 END",
+				goqu.T(s.table), goqu.T(s.table)),
+			"window_start": goqu.L("EXCLUDED.window_start"),
+			"expires_at":   goqu.L("EXCLUDED.expires_at"),
+		})).
+		Returning("hits")
+
+	sql, args, err := query.ToSQL()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to build query")
+	}
+
+	var hits int
+	if err = s.pool.QueryRow(ctx, sql, args...).Scan(&hits); err != nil {
+		return 0, errors.Wrap(err, "failed to execute query")
+	}
+
+	return hits, nil
+}
+
+// sweep deletes the counters of ended windows at most once a minute per
+// instance. Failures are left to the next sweep.
+func (s *PostgresStore) sweep(ctx context.Context, now time.Time) {
+	last := s.lastSweep.Load()
+	if now.Sub(time.Unix(0, last)) < time.Minute || !s.lastSweep.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+
+	query := goqu.Dialect("postgres").
+		Delete(s.table).
+		Where(goqu.C("expires_at").Lte(now))
+
+	sql, args, err := query.ToSQL()
+	if err != nil {
+		return
+	}
+	_, _ = s.pool.Exec(ctx, sql, args...)
+}
@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// Class groups routes that share a budget.
+type Class string
+
+const (
+	ClassRead   Class = "read"
+	ClassWrite  Class = "write"
+	ClassSearch Class = "search"
+	// ClassAuth counts the requests that carry an API key per IP address,
+	// so that guessing keys is throttled.
+	ClassAuth Class = "auth"
+)
+
+// Names of the stores, as configured by RATE_LIMIT_STORE.
+const (
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+)
+
+// Limit allows Requests requests per fixed Window.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+type Result struct {
+	Limit     Limit
+	Allowed   bool
+	Remaining int
+	// Reset is the time left until the current window ends.
+	Reset time.Duration
+}
+
+// Store counts hits per key in fixed windows. Implementations must be safe
+// for concurrent use.
+type Store interface {
+	// Hit records a hit in the window starting at windowStart and returns
+	// the number of hits in that window so far.
+	Hit(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int, error)
+}
+
+type Limiter struct {
+	store  Store
+	limits map[Class]Limit
+	now    func() time.Time
+}
+
+func NewLimiter(store Store, limits map[Class]Limit) *Limiter {
+	return &Limiter{
+		store:  store,
+		limits: limits,
+		now:    time.Now,
+	}
+}
+
+// Allow counts a request of the client against the budget of the class.
+// Classes without a limit are not limited.
+func (l *Limiter) Allow(ctx context.Context, class Class, client string) (*Result, error) {
+	limit, ok := l.limits[class]
+	if !ok || limit.Requests <= 0 || limit.Window <= 0 {
+		return nil, nil
+	}
+
+	now := l.now()
+	windowStart := now.Truncate(limit.Window)
+
+	hits, err := l.store.Hit(ctx, string(class)+":"+client, windowStart, limit.Window)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to count request")
+	}
+
+	return &Result{
+		Limit:     limit,
+		Allowed:   hits <= limit.Requests,
+		Remaining: max(limit.Requests-hits, 0),
+		Reset:     windowStart.Add(limit.Window).Sub(now),
+	}, nil
+}
@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"testing"
+	"time"
+)
+
+type failingStore struct{}
+
+func (failingStore) Hit(context.Context, string, time.Time, time.Duration) (int, error) {
+	return 0, errors.New("store is down")
+}
+
+func newTestLimiter(store Store, now *time.Time) *Limiter {
+	limiter := NewLimiter(store, map[Class]Limit{
+		ClassRead:  {Requests: 2, Window: time.Minute},
+		ClassWrite: {Requests: 0, Window: time.Minute},
+	})
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+func TestLimiterAllow(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, time.March, 1, 12, 0, 15, 0, time.UTC)
+	limiter := newTestLimiter(NewMemoryStore(), &now)
+
+	steps := []struct {
+		name          string
+		advance       time.Duration
+		client        string
+		wantAllowed   bool
+		wantRemaining int
+		wantReset     time.Duration
+	}{
+		{name: "first request", client: "a", wantAllowed: true, wantRemaining: 1, wantReset: 45 * time.Second},
+		{name: "last request of the budget", advance: 5 * time.Second, client: "a", wantAllowed: true, wantRemaining: 0, wantReset: 40 * time.Second},
+		{name: "over the budget", client: "a", wantAllowed: false, wantRemaining: 0, wantReset: 40 * time.Second},
+		{name: "other client has its own budget", client: "b", wantAllowed: true, wantRemaining: 1, wantReset: 40 * time.Second},
+		{name: "next window restarts the count", advance: 40 * time.Second, client: "a", wantAllowed: true, wantRemaining: 1, wantReset: time.Minute},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+
+		result, err := limiter.Allow(ctx, ClassRead, step.client)
+		if err != nil {
+			t.Fatalf("%s: Allow() error = %v", step.name, err)
+		}
+		if result == nil {
+			t.Fatalf("%s: Allow() = nil, want a result", step.name)
+		}
+		if result.Allowed != step.wantAllowed || result.Remaining != step.wantRemaining || result.Reset != step.wantReset {
+			t.Errorf("%s: Allow() = {Allowed: %t, Remaining: %d, Reset: %s}, want {Allowed: %t, Remaining: %d, Reset: %s}",
+				step.name, result.Allowed, result.Remaining, result.Reset, step.wantAllowed, step.wantRemaining, step.wantReset)
+		}
+	}
+}
+
+func TestLimiterAllowSeparatesClasses(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(NewMemoryStore(), map[Class]Limit{
+		ClassRead:   {Requests: 1, Window: time.Minute},
+		ClassSearch: {Requests: 1, Window: time.Minute},
+	})
+	limiter.now = func() time.Time { return now }
+
+	for _, class := range []Class{ClassRead, ClassSearch} {
+		result, err := limiter.Allow(ctx, class, "a")
+		if err != nil {
+			t.Fatalf("Allow(%s) error = %v", class, err)
+		}
+		if !result.Allowed {
+			t.Errorf("Allow(%s) was not allowed", class)
+		}
+	}
+}
+
+func TestLimiterAllowUnlimited(t *testing.T) {
+	now := time.Now()
+	limiter := newTestLimiter(NewMemoryStore(), &now)
+
+	for _, class := range []Class{ClassWrite, ClassSearch} {
+		result, err := limiter.Allow(context.Background(), class, "a")
+		if err != nil {
+			t.Fatalf("Allow(%s) error = %v", class, err)
+		}
+		if result != nil {
+			t.Errorf("Allow(%s) = %+v, want no limit", class, result)
+		}
+	}
+}
+
+func TestLimiterAllowStoreError(t *testing.T) {
+	now := time.Now()
+	limiter := newTestLimiter(failingStore{}, &now)
+
+	if _, err := limiter.Allow(context.Background(), ClassRead, "a"); err == nil {
+		t.Error("Allow() error = nil, want the store error")
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, err := store.Hit(ctx, "a", start, time.Minute); err != nil {
+		t.Fatalf("Hit() error = %v", err)
+	}
+	if _, err := store.Hit(ctx, "b", start.Add(2*time.Minute), time.Minute); err != nil {
+		t.Fatalf("Hit() error = %v", err)
+	}
+
+	if _, ok := store.counters["a"]; ok {
+		t.Error("counter of an ended window was not swept")
+	}
+	if _, ok := store.counters["b"]; !ok {
+		t.Error("counter of the current window was swept")
+	}
+}
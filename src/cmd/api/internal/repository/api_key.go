@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"song-library-api/src/cmd/api/internal/converter"
+	"song-library-api/src/cmd/api/internal/model"
+)
+
+var _ APIKeyRepository = (*apiKeyRepository)(nil)
+
+type apiKeyRepository struct {
+	pool      *pgxpool.Pool
+	getter    *trmpgx.CtxGetter
+	trManager *manager.Manager
+}
+
+func NewAPIKeyRepository(
+	pool *pgxpool.Pool,
+	getter *trmpgx.CtxGetter,
+	trManager *manager.Manager) *apiKeyRepository {
+	return &apiKeyRepository{
+		pool:      pool,
+		getter:    getter,
+		trManager: trManager,
+	}
+}
+
+func (repo *apiKeyRepository) Create(ctx context.Context, entity model.APIKey) (*model.APIKey, error) {
+	record := converter.ToRecordFromAPIKey(entity)
+
+	query := goqu.Dialect("postgres").
+		Insert("api_key").
+		Rows(record).
+		Returning("api_key.*")
+
+	sql, args, err := query.ToSQL()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build query")
+	}
+
+	var apiKey model.APIKey
+	tr := repo.getter.DefaultTrOrDB(ctx, repo.pool)
+	if err = pgxscan.Get(ctx, tr, &apiKey, sql, args...); err != nil {
+		return nil, errors.Wrap(err, "failed to execute query")
+	}
+
+	return &apiKey, nil
+}
+
+func (repo *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	query := goqu.Dialect("postgres").
+		From("api_key").
+		Select("api_key.*").
+		Where(goqu.Ex{"key_hash": keyHash})
+
+	sql, args, err := query.ToSQL()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build query")
+	}
+
+	var apiKey model.APIKey
+	tr := repo.getter.DefaultTrOrDB(ctx, repo.pool)
+	if err = pgxscan.Get(ctx, tr, &apiKey, sql, args...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(model.ErrNotFound, "api key not found")
+		}
+		return nil, errors.Wrap(err, "failed to execute query")
+	}
+
+	return &apiKey, nil
+}
+
+// Revoke marks the key as revoked. Revoking a revoked key keeps the original time.
+func (repo *apiKeyRepository) Revoke(ctx context.Context, id uuid.UUID) (*model.APIKey, error) {
+	query := goqu.Dialect("postgres").
+		Update("api_key").
+		Set(goqu.Record{
+			"revoked_at": goqu.L("COALESCE(revoked_at, CURRENT_TIMESTAMP)"),
+			"updated_at": goqu.L("CURRENT_TIMESTAMP"),
+		}).
+		Where(goqu.Ex{"id": id}).
+		Returning("api_key.*")
+
+	sql, args, err := query.ToSQL()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build query")
+	}
+
+	var apiKey model.APIKey
+	tr := repo.getter.DefaultTrOrDB(ctx, repo.pool)
+	if err = pgxscan.Get(ctx, tr, &apiKey, sql, args...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(model.ErrNotFound, "api key not found")
+		}
+		return nil, errors.Wrap(err, "failed to execute query")
+	}
+
+	return &apiKey, nil
+}
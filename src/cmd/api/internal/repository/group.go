@@ -139,3 +139,21 @@ func (repo *groupRepository) Update(ctx context.Context, entity model.Group) (*m
 
 	return &group, nil
 }
+
+func (repo *groupRepository) Delete(ctx context.Context, group model.Group) error {
+	query := goqu.Dialect("postgres").
+		Delete("group").
+		Where(goqu.Ex{"id": group.ID})
+
+	sql, args, err := query.ToSQL()
+	if err != nil {
+		return errors.Wrap(err, "failed to build query")
+	}
+
+	tr := repo.getter.DefaultTrOrDB(ctx, repo.pool)
+	if _, err = tr.Exec(ctx, sql, args...); err != nil {
+		return errors.Wrap(err, "failed to execute query")
+	}
+
+	return nil
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/cmd/api/internal/tracing"
+	"time"
+)
+
+// Observer records repository method durations.
+type Observer interface {
+	ObserveQuery(repository, method string, elapsed time.Duration, err error)
+}
+
+var spanPrefixes = map[string]string{
+	"song":    "SongRepository.",
+	"group":   "GroupRepository.",
+	"job":     "JobRepository.",
+	"api_key": "APIKeyRepository.",
+}
+
+// instrument starts the span of a repository method. The returned function
+// ends it and records the duration and the error of the method.
+func instrument(ctx context.Context, observer Observer, repository, method string) (context.Context, func(err *error)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, spanPrefixes[repository]+method)
+	return ctx, func(err *error) {
+		observer.ObserveQuery(repository, method, time.Since(start), *err)
+		tracing.End(span, err)
+	}
+}
+
+var _ SongRepository = (*songRepositoryInstrumented)(nil)
+
+type songRepositoryInstrumented struct {
+	base     SongRepository
+	observer Observer
+}
+
+func NewSongRepositoryWithInstrumentation(base SongRepository, observer Observer) *songRepositoryInstrumented {
+	return &songRepositoryInstrumented{
+		base:     base,
+		observer: observer,
+	}
+}
+
+func (repo *songRepositoryInstrumented) GetSongs(ctx context.Context, filters *model.SongFilter, limit, offset uint) (songs []model.Song, err error) {
+	ctx, end := instrument(ctx, repo.observer, "song", "GetSongs")
+	defer end(&err)
+	return repo.base.GetSongs(ctx, filters, limit, offset)
+}
+
+func (repo *songRepositoryInstrumented) GetSongsAfter(ctx context.Context, filters *model.SongFilter, afterID uuid.UUID, limit uint) (songs []model.Song, err error) {
+	ctx, end := instrument(ctx, repo.observer, "song", "GetSongsAfter")
+	defer end(&err)
+	return repo.base.GetSongsAfter(ctx, filters, afterID, limit)
+}
+
+func (repo *songRepositoryInstrumented) Stream(ctx context.Context, filters *model.SongFilter, fetchSize uint, fn func(song model.Song) error) (err error) {
+	ctx, end := instrument(ctx, repo.observer, "song", "Stream")
+	defer end(&err)
+	return repo.base.Stream(ctx, filters, fetchSize, fn)
+}
+
+func (repo *songRepositoryInstrumented) GetByID(ctx context.Context, id uuid.UUID) (song *model.Song, err error) {
+	ctx, end := instrument(ctx, repo.observer, "song", "GetByID")
+	defer end(&err)
+	return repo.base.GetByID(ctx, id)
+}
+
+func (repo *songRepositoryInstrumented) GetByNameAndGroup(ctx context.Context, group, name string) (song *model.Song, err error) {
+	ctx, end := instrument(ctx, repo.observer, "song", "GetByNameAndGroup")
+	defer end(&err)
+	return repo.base.GetByNameAndGroup(ctx, group, name)
+}
+
+func (repo *songRepositoryInstrumented) GetExisting(ctx context.Context, songs []model.Song) (existing []model.Song, err error) {
+	ctx, end := instrument(ctx, repo.observer, "song", "GetExisting")
+	defer end(&err)
+	return repo.base.GetExisting(ctx, songs)
+}
+
+func (repo *songRepositoryInstrumented) Count(ctx context.Context, filters *model.SongFilter) (total uint, err error) {
+	ctx, end := instrument(ctx, repo.observer, "song", "Count")
+	defer end(&err)
+	return repo.base.Count(ctx, filters)
+}
+
+func (repo *songRepositoryInstrumented) Create(ctx context.Context, entity model.Song) (song *model.Song, err error) {
+	ctx, end := instrument(ctx, repo.observer, "song", "Create")
+	defer end(&err)
+	return repo.base.Create(ctx, entity)
+}
+
+func (repo *songRepositoryInstrumented) CopyNew(ctx context.Context, entities []model.Song) (ids []uuid.UUID, err error) {
+	ctx, end := instrument(ctx, repo.observer, "song", "CopyNew")
+	defer end(&err)
+	return repo.base.CopyNew(ctx, entities)
+}
+
+func (repo *songRepositoryInstrumented) Update(ctx context.Context, entity model.Song) (song *model.Song, err error) {
+	ctx, end := instrument(ctx, repo.observer, "song", "Update")
+	defer end(&err)
+	return repo.base.Update(ctx, entity)
+}
+
+func (repo *songRepositoryInstrumented) MoveToGroup(ctx context.Context, fromGroupID, toGroupID uuid.UUID) (count int64, skipped []string, err error) {
+	ctx, end := instrument(ctx, repo.observer, "song", "MoveToGroup")
+	defer end(&err)
+	return repo.base.MoveToGroup(ctx, fromGroupID, toGroupID)
+}
+
+func (repo *songRepositoryInstrumented) Delete(ctx context.Context, song model.Song) (err error) {
+	ctx, end := instrument(ctx, repo.observer, "song", "Delete")
+	defer end(&err)
+	return repo.base.Delete(ctx, song)
+}
+
+var _ GroupRepository = (*groupRepositoryInstrumented)(nil)
+
+type groupRepositoryInstrumented struct {
+	base     GroupRepository
+	observer Observer
+}
+
+func NewGroupRepositoryWithInstrumentation(base GroupRepository, observer Observer) *groupRepositoryInstrumented {
+	return &groupRepositoryInstrumented{
+		base:     base,
+		observer: observer,
+	}
+}
+
+func (repo *groupRepositoryInstrumented) GetByID(ctx context.Context, id uuid.UUID) (group *model.Group, err error) {
+	ctx, end := instrument(ctx, repo.observer, "group", "GetByID")
+	defer end(&err)
+	return repo.base.GetByID(ctx, id)
+}
+
+func (repo *groupRepositoryInstrumented) GetByName(ctx context.Context, name string) (group *model.Group, err error) {
+	ctx, end := instrument(ctx, repo.observer, "group", "GetByName")
+	defer end(&err)
+	return repo.base.GetByName(ctx, name)
+}
+
+func (repo *groupRepositoryInstrumented) Create(ctx context.Context, entity model.Group) (group *model.Group, err error) {
+	ctx, end := instrument(ctx, repo.observer, "group", "Create")
+	defer end(&err)
+	return repo.base.Create(ctx, entity)
+}
+
+func (repo *groupRepositoryInstrumented) Update(ctx context.Context, entity model.Group) (group *model.Group, err error) {
+	ctx, end := instrument(ctx, repo.observer, "group", "Update")
+	defer end(&err)
+	return repo.base.Update(ctx, entity)
+}
+
+func (repo *groupRepositoryInstrumented) Delete(ctx context.Context, group model.Group) (err error) {
+	ctx, end := instrument(ctx, repo.observer, "group", "Delete")
+	defer end(&err)
+	return repo.base.Delete(ctx, group)
+}
+
+var _ JobRepository = (*jobRepositoryInstrumented)(nil)
+
+type jobRepositoryInstrumented struct {
+	base     JobRepository
+	observer Observer
+}
+
+func NewJobRepositoryWithInstrumentation(base JobRepository, observer Observer) *jobRepositoryInstrumented {
+	return &jobRepositoryInstrumented{
+		base:     base,
+		observer: observer,
+	}
+}
+
+func (repo *jobRepositoryInstrumented) Enqueue(ctx context.Context, entity model.Job) (job *model.Job, err error) {
+	ctx, end := instrument(ctx, repo.observer, "job", "Enqueue")
+	defer end(&err)
+	return repo.base.Enqueue(ctx, entity)
+}
+
+func (repo *jobRepositoryInstrumented) EnqueueMany(ctx context.Context, entities []model.Job) (count int64, err error) {
+	ctx, end := instrument(ctx, repo.observer, "job", "EnqueueMany")
+	defer end(&err)
+	return repo.base.EnqueueMany(ctx, entities)
+}
+
+func (repo *jobRepositoryInstrumented) Dequeue(ctx context.Context, lockTimeout time.Duration) (job *model.Job, err error) {
+	ctx, end := instrument(ctx, repo.observer, "job", "Dequeue")
+	defer end(&err)
+	return repo.base.Dequeue(ctx, lockTimeout)
+}
+
+func (repo *jobRepositoryInstrumented) Complete(ctx context.Context, job model.Job) (err error) {
+	ctx, end := instrument(ctx, repo.observer, "job", "Complete")
+	defer end(&err)
+	return repo.base.Complete(ctx, job)
+}
+
+func (repo *jobRepositoryInstrumented) Retry(ctx context.Context, job model.Job, lastError string, delay time.Duration) (err error) {
+	ctx, end := instrument(ctx, repo.observer, "job", "Retry")
+	defer end(&err)
+	return repo.base.Retry(ctx, job, lastError, delay)
+}
+
+func (repo *jobRepositoryInstrumented) Bury(ctx context.Context, job model.Job, lastError string) (err error) {
+	ctx, end := instrument(ctx, repo.observer, "job", "Bury")
+	defer end(&err)
+	return repo.base.Bury(ctx, job, lastError)
+}
+
+var _ APIKeyRepository = (*apiKeyRepositoryInstrumented)(nil)
+
+type apiKeyRepositoryInstrumented struct {
+	base     APIKeyRepository
+	observer Observer
+}
+
+func NewAPIKeyRepositoryWithInstrumentation(base APIKeyRepository, observer Observer) *apiKeyRepositoryInstrumented {
+	return &apiKeyRepositoryInstrumented{
+		base:     base,
+		observer: observer,
+	}
+}
+
+func (repo *apiKeyRepositoryInstrumented) Create(ctx context.Context, entity model.APIKey) (apiKey *model.APIKey, err error) {
+	ctx, end := instrument(ctx, repo.observer, "api_key", "Create")
+	defer end(&err)
+	return repo.base.Create(ctx, entity)
+}
+
+func (repo *apiKeyRepositoryInstrumented) GetByHash(ctx context.Context, keyHash string) (apiKey *model.APIKey, err error) {
+	ctx, end := instrument(ctx, repo.observer, "api_key", "GetByHash")
+	defer end(&err)
+	return repo.base.GetByHash(ctx, keyHash)
+}
+
+func (repo *apiKeyRepositoryInstrumented) Revoke(ctx context.Context, id uuid.UUID) (apiKey *model.APIKey, err error) {
+	ctx, end := instrument(ctx, repo.observer, "api_key", "Revoke")
+	defer end(&err)
+	return repo.base.Revoke(ctx, id)
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"song-library-api/src/cmd/api/internal/converter"
+	"song-library-api/src/cmd/api/internal/model"
+	"time"
+)
+
+var _ JobRepository = (*jobRepository)(nil)
+
+type jobRepository struct {
+	pool      *pgxpool.Pool
+	getter    *trmpgx.CtxGetter
+	trManager *manager.Manager
+}
+
+func NewJobRepository(
+	pool *pgxpool.Pool,
+	getter *trmpgx.CtxGetter,
+	trManager *manager.Manager) *jobRepository {
+	return &jobRepository{
+		pool:      pool,
+		getter:    getter,
+		trManager: trManager,
+	}
+}
+
+func (repo *jobRepository) Enqueue(ctx context.Context, entity model.Job) (*model.Job, error) {
+	record := converter.ToRecordFromJob(entity)
+
+	query := goqu.Dialect("postgres").
+		Insert("job").
+		Rows(record).
+		Returning("job.*")
+
+	sql, args, err := query.ToSQL()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build query")
+	}
+
+	var job model.Job
+	tr := repo.getter.DefaultTrOrDB(ctx, repo.pool)
+	if err = pgxscan.Get(ctx, tr, &job, sql, args...); err != nil {
+		return nil, errors.Wrap(err, "failed to execute query")
+	}
+
+	return &job, nil
+}
+
+func (repo *jobRepository) EnqueueMany(ctx context.Context, entities []model.Job) (int64, error) {
+	if len(entities) == 0 {
+		return 0, nil
+	}
+
+	rows := make([]any, 0, len(entities))
+	for _, entity := range entities {
+		rows = append(rows, converter.ToRecordFromJob(entity))
+	}
+
+	query := goqu.Dialect("postgres").
+		Insert("job").
+		Rows(rows...)
+
+	sql, args, err := query.ToSQL()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to build query")
+	}
+
+	tr := repo.getter.DefaultTrOrDB(ctx, repo.pool)
+	tag, err := tr.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to execute query")
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// Dequeue claims the oldest due job. Jobs stuck in the running state for longer
+// than lockTimeout are considered abandoned by a crashed worker and are claimed again.
+func (repo *jobRepository) Dequeue(ctx context.Context, lockTimeout time.Duration) (*model.Job, error) {
+	next := goqu.Dialect("postgres").
+		From("job").
+		Select("id").
+		Where(goqu.Or(
+			goqu.And(
+				goqu.Ex{"status": model.JobStatusPending},
+				goqu.I("scheduled_at").Lte(goqu.L("CURRENT_TIMESTAMP")),
+			),
+			goqu.And(
+				goqu.Ex{"status": model.JobStatusRunning},
+				goqu.I("locked_at").Lt(goqu.L("CURRENT_TIMESTAMP - ?::interval", toInterval(lockTimeout))),
+			),
+		)).
+		Order(goqu.I("scheduled_at").Asc()).
+		Limit(1).
+		ForUpdate(exp.SkipLocked)
+
+	query := goqu.Dialect("postgres").
+		Update("job").
+		Set(goqu.Record{
+			"status":     model.JobStatusRunning,
+			"attempts":   goqu.L("attempts + 1"),
+			"locked_at":  goqu.L("CURRENT_TIMESTAMP"),
+			"updated_at": goqu.L("CURRENT_TIMESTAMP"),
+		}).
+		Where(goqu.I("id").Eq(next)).
+		Returning("job.*")
+
+	sql, args, err := query.ToSQL()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build query")
+	}
+
+	var job model.Job
+	tr := repo.getter.DefaultTrOrDB(ctx, repo.pool)
+	if err = pgxscan.Get(ctx, tr, &job, sql, args...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(model.ErrNotFound, "no job available")
+		}
+		return nil, errors.Wrap(err, "failed to execute query")
+	}
+
+	return &job, nil
+}
+
+func (repo *jobRepository) Complete(ctx context.Context, job model.Job) error {
+	return repo.update(ctx, job, goqu.Record{
+		"status":     model.JobStatusDone,
+		"locked_at":  nil,
+		"last_error": "",
+	})
+}
+
+func (repo *jobRepository) Retry(ctx context.Context, job model.Job, lastError string, delay time.Duration) error {
+	return repo.update(ctx, job, goqu.Record{
+		"status":       model.JobStatusPending,
+		"locked_at":    nil,
+		"last_error":   lastError,
+		"scheduled_at": goqu.L("CURRENT_TIMESTAMP + ?::interval", toInterval(delay)),
+	})
+}
+
+func (repo *jobRepository) Bury(ctx context.Context, job model.Job, lastError string) error {
+	return repo.update(ctx, job, goqu.Record{
+		"status":     model.JobStatusDead,
+		"locked_at":  nil,
+		"last_error": lastError,
+	})
+}
+
+// update changes the state of a job claimed by Dequeue. It fails with
+// ErrJobLockLost if the job has been claimed again since, because its lock
+// timed out, so that a stale worker cannot overwrite the state set by the
+// worker holding the lock now.
+func (repo *jobRepository) update(ctx context.Context, job model.Job, record goqu.Record) error {
+	if job.LockedAt == nil {
+		return errors.Wrap(model.ErrJobLockLost, "job is not locked")
+	}
+	record["updated_at"] = goqu.L("CURRENT_TIMESTAMP")
+
+	query := goqu.Dialect("postgres").
+		Update("job").
+		Set(record).
+		Where(goqu.Ex{
+			"id":        job.ID,
+			"status":    model.JobStatusRunning,
+			"locked_at": *job.LockedAt,
+		})
+
+	sql, args, err := query.ToSQL()
+	if err != nil {
+		return errors.Wrap(err, "failed to build query")
+	}
+
+	tr := repo.getter.DefaultTrOrDB(ctx, repo.pool)
+	tag, err := tr.Exec(ctx, sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute query")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(model.ErrJobLockLost, "job was claimed again")
+	}
+
+	return nil
+}
+
+func toInterval(d time.Duration) string {
+	return fmt.Sprintf("%d milliseconds", d.Milliseconds())
+}
@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"os"
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/cmd/api/migrations"
+	"testing"
+	"time"
+)
+
+// testPool connects to the database of TEST_POSTGRES_CONN and migrates it.
+// The tests empty the tables they use, so it must be a disposable database.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	conn := os.Getenv("TEST_POSTGRES_CONN")
+	if conn == "" {
+		t.Skip("TEST_POSTGRES_CONN is not set")
+	}
+
+	source, err := iofs.New(migrations.FS, migrations.PostgreSQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.NewWithSourceInstance("iofs", source, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if err = m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatal(err)
+	}
+
+	pool, err := pgxpool.New(context.Background(), conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	return pool
+}
+
+// newTestJobRepository returns a repository on an empty job table, with the
+// jobs of insert added to it.
+func newTestJobRepository(t *testing.T, insert ...string) *jobRepository {
+	t.Helper()
+
+	pool := testPool(t)
+	ctx := context.Background()
+	if _, err := pool.Exec(ctx, "TRUNCATE job"); err != nil {
+		t.Fatal(err)
+	}
+	for _, sql := range insert {
+		if _, err := pool.Exec(ctx, sql); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return NewJobRepository(pool, trmpgx.DefaultCtxGetter, manager.Must(trmpgx.NewDefaultFactory(pool)))
+}
+
+func TestJobRepository_Dequeue(t *testing.T) {
+	tests := []struct {
+		name     string
+		insert   []string
+		wantType string
+		wantErr  error
+	}{
+		{
+			name:    "empty queue",
+			wantErr: model.ErrNotFound,
+		},
+		{
+			name: "due pending job",
+			insert: []string{
+				`INSERT INTO job (type) VALUES ('due')`,
+			},
+			wantType: "due",
+		},
+		{
+			name: "scheduled in the future",
+			insert: []string{
+				`INSERT INTO job (type, scheduled_at) VALUES ('later', CURRENT_TIMESTAMP + interval '1 hour')`,
+			},
+			wantErr: model.ErrNotFound,
+		},
+		{
+			name: "oldest scheduled first",
+			insert: []string{
+				`INSERT INTO job (type, scheduled_at) VALUES ('newer', CURRENT_TIMESTAMP - interval '1 minute')`,
+				`INSERT INTO job (type, scheduled_at) VALUES ('older', CURRENT_TIMESTAMP - interval '2 minutes')`,
+			},
+			wantType: "older",
+		},
+		{
+			name: "running job within its lock",
+			insert: []string{
+				`INSERT INTO job (type, status, attempts, locked_at) VALUES ('locked', 'running', 1, CURRENT_TIMESTAMP)`,
+			},
+			wantErr: model.ErrNotFound,
+		},
+		{
+			name: "running job with an expired lock",
+			insert: []string{
+				`INSERT INTO job (type, status, attempts, locked_at) VALUES ('abandoned', 'running', 1, CURRENT_TIMESTAMP - interval '1 hour')`,
+			},
+			wantType: "abandoned",
+		},
+		{
+			name: "finished jobs",
+			insert: []string{
+				`INSERT INTO job (type, status) VALUES ('done', 'done')`,
+				`INSERT INTO job (type, status) VALUES ('dead', 'dead')`,
+			},
+			wantErr: model.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestJobRepository(t, tt.insert...)
+
+			job, err := repo.Dequeue(context.Background(), time.Minute)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Dequeue() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Dequeue() error = %v", err)
+			}
+			if job.Type != tt.wantType || job.Status != model.JobStatusRunning || job.LockedAt == nil {
+				t.Errorf("Dequeue() = %s %s %v, want %s running and locked", job.Type, job.Status, job.LockedAt, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestJobRepository_Dequeue_countsAttempts(t *testing.T) {
+	repo := newTestJobRepository(t,
+		`INSERT INTO job (type, status, attempts, locked_at) VALUES ('abandoned', 'running', 2, CURRENT_TIMESTAMP - interval '1 hour')`)
+
+	job, err := repo.Dequeue(context.Background(), time.Minute)
+	if err != nil {
+		t.Fatalf("Dequeue() error = %v", err)
+	}
+	if job.Attempts != 3 {
+		t.Errorf("Attempts = %d, want 3", job.Attempts)
+	}
+}
+
+func TestJobRepository_update(t *testing.T) {
+	ctx := context.Background()
+	stale := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		update  func(repo *jobRepository, job model.Job) error
+		lock    func(job *model.Job)
+		want    model.JobStatus
+		wantErr error
+	}{
+		{
+			name:   "complete",
+			update: func(repo *jobRepository, job model.Job) error { return repo.Complete(ctx, job) },
+			want:   model.JobStatusDone,
+		},
+		{
+			name: "retry",
+			update: func(repo *jobRepository, job model.Job) error {
+				return repo.Retry(ctx, job, "failed", time.Minute)
+			},
+			want: model.JobStatusPending,
+		},
+		{
+			name:   "bury",
+			update: func(repo *jobRepository, job model.Job) error { return repo.Bury(ctx, job, "failed") },
+			want:   model.JobStatusDead,
+		},
+		{
+			name:    "not locked",
+			update:  func(repo *jobRepository, job model.Job) error { return repo.Complete(ctx, job) },
+			lock:    func(job *model.Job) { job.LockedAt = nil },
+			want:    model.JobStatusRunning,
+			wantErr: model.ErrJobLockLost,
+		},
+		{
+			name:    "claimed again",
+			update:  func(repo *jobRepository, job model.Job) error { return repo.Complete(ctx, job) },
+			lock:    func(job *model.Job) { job.LockedAt = &stale },
+			want:    model.JobStatusRunning,
+			wantErr: model.ErrJobLockLost,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestJobRepository(t, `INSERT INTO job (type) VALUES ('test')`)
+
+			job, err := repo.Dequeue(ctx, time.Minute)
+			if err != nil {
+				t.Fatalf("Dequeue() error = %v", err)
+			}
+			claimed := *job
+			if tt.lock != nil {
+				tt.lock(&claimed)
+			}
+
+			err = tt.update(repo, claimed)
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("update error = %v, want %v", err, tt.wantErr)
+			}
+
+			var status model.JobStatus
+			if err = repo.pool.QueryRow(ctx, "SELECT status FROM job WHERE id = $1", job.ID).Scan(&status); err != nil {
+				t.Fatal(err)
+			}
+			if status != tt.want {
+				t.Errorf("status = %s, want %s", status, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobRepository_update_staleWorker(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestJobRepository(t, `INSERT INTO job (type) VALUES ('test')`)
+
+	first, err := repo.Dequeue(ctx, time.Minute)
+	if err != nil {
+		t.Fatalf("Dequeue() error = %v", err)
+	}
+	// A zero lock timeout lets the second worker claim the job at once.
+	second, err := repo.Dequeue(ctx, 0)
+	if err != nil {
+		t.Fatalf("Dequeue() error = %v", err)
+	}
+	if second.ID != first.ID {
+		t.Fatalf("Dequeue() = %s, want %s claimed again", second.ID, first.ID)
+	}
+
+	if err = repo.Complete(ctx, *first); !errors.Is(err, model.ErrJobLockLost) {
+		t.Errorf("Complete() by the stale worker error = %v, want ErrJobLockLost", err)
+	}
+	if err = repo.Complete(ctx, *second); err != nil {
+		t.Errorf("Complete() by the current worker error = %v", err)
+	}
+}
@@ -4,15 +4,21 @@ import (
 	"context"
 	"github.com/google/uuid"
 	"song-library-api/src/cmd/api/internal/model"
+	"time"
 )
 
 type SongRepository interface {
 	GetSongs(ctx context.Context, filters *model.SongFilter, limit, offset uint) ([]model.Song, error)
+	GetSongsAfter(ctx context.Context, filters *model.SongFilter, afterID uuid.UUID, limit uint) ([]model.Song, error)
+	Stream(ctx context.Context, filters *model.SongFilter, fetchSize uint, fn func(song model.Song) error) error
 	GetByID(ctx context.Context, id uuid.UUID) (*model.Song, error)
 	GetByNameAndGroup(ctx context.Context, group, name string) (*model.Song, error)
+	GetExisting(ctx context.Context, songs []model.Song) ([]model.Song, error)
 	Count(ctx context.Context, filters *model.SongFilter) (uint, error)
 	Create(ctx context.Context, entity model.Song) (*model.Song, error)
+	CopyNew(ctx context.Context, entities []model.Song) ([]uuid.UUID, error)
 	Update(ctx context.Context, entity model.Song) (*model.Song, error)
+	MoveToGroup(ctx context.Context, fromGroupID, toGroupID uuid.UUID) (int64, []string, error)
 	Delete(ctx context.Context, song model.Song) error
 }
 
@@ -21,4 +27,20 @@ type GroupRepository interface {
 	GetByName(ctx context.Context, name string) (*model.Group, error)
 	Create(ctx context.Context, entity model.Group) (*model.Group, error)
 	Update(ctx context.Context, entity model.Group) (*model.Group, error)
+	Delete(ctx context.Context, group model.Group) error
+}
+
+type JobRepository interface {
+	Enqueue(ctx context.Context, entity model.Job) (*model.Job, error)
+	EnqueueMany(ctx context.Context, entities []model.Job) (int64, error)
+	Dequeue(ctx context.Context, lockTimeout time.Duration) (*model.Job, error)
+	Complete(ctx context.Context, job model.Job) error
+	Retry(ctx context.Context, job model.Job, lastError string, delay time.Duration) error
+	Bury(ctx context.Context, job model.Job, lastError string) error
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, entity model.APIKey) (*model.APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*model.APIKey, error)
+	Revoke(ctx context.Context, id uuid.UUID) (*model.APIKey, error)
 }
@@ -2,16 +2,20 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
 	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
 	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
 	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pkg/errors"
 	"song-library-api/src/cmd/api/internal/converter"
+	"song-library-api/src/cmd/api/internal/db/postgres"
 	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/pkg/partial_date"
 )
 
 var _ SongRepository = (*songRepository)(nil)
@@ -49,25 +53,44 @@ func (repo *songRepository) GetSongs(ctx context.Context,
 		Order(goqu.I("song.release_date").Asc()).
 		Limit(limit).
 		Offset(offset)
+	query = withSongFilter(query, filters)
 
-	if filters != nil {
-		if filters.GroupID != uuid.Nil {
-			query = query.Where(goqu.Ex{"group_id": filters.GroupID})
-		}
-		if filters.Song != nil {
-			query = query.Where(goqu.Ex{"song": goqu.Op{"ilike": "%" + *filters.Song + "%"}})
-		}
-		if filters.Text != nil {
-			query = query.Where(goqu.Ex{"text": goqu.Op{"ilike": "%" + *filters.Text + "%"}})
-		}
-		if filters.Link != nil {
-			query = query.Where(goqu.Ex{"link": goqu.Op{"ilike": "%" + *filters.Link + "%"}})
-		}
-		if filters.ReleaseDate != nil {
-			query = query.Where(goqu.I("release_date").Eq(*filters.ReleaseDate))
-		}
+	sql, args, err := query.ToSQL()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build query")
+	}
+
+	songs := make([]model.Song, 0)
+	tr := repo.getter.DefaultTrOrDB(ctx, repo.pool)
+	err = pgxscan.Select(ctx, tr, &songs, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to execute query")
 	}
 
+	return songs, nil
+}
+
+// GetSongsAfter returns up to limit songs with an id greater than afterID,
+// ordered by id.
+func (repo *songRepository) GetSongsAfter(ctx context.Context,
+	filters *model.SongFilter,
+	afterID uuid.UUID,
+	limit uint) ([]model.Song, error) {
+	query := goqu.Dialect("postgres").
+		From("song").
+		Join(
+			goqu.T("group"),
+			goqu.On(goqu.I("song.group_id").Eq(goqu.I("group.id"))),
+		).
+		Select(
+			goqu.I("song.*"),
+			goqu.I("group.name").As("group"),
+		).
+		Where(goqu.I("song.id").Gt(afterID)).
+		Order(goqu.I("song.id").Asc()).
+		Limit(limit)
+	query = withSongFilter(query, filters)
+
 	sql, args, err := query.ToSQL()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to build query")
@@ -83,6 +106,66 @@ func (repo *songRepository) GetSongs(ctx context.Context,
 	return songs, nil
 }
 
+// Stream passes every song matching filters to fn, reading fetchSize rows of
+// a cursor at a time. Returning an error from fn stops the stream.
+func (repo *songRepository) Stream(ctx context.Context,
+	filters *model.SongFilter,
+	fetchSize uint,
+	fn func(song model.Song) error) error {
+	query := goqu.Dialect("postgres").
+		From("song").
+		Join(
+			goqu.T("group"),
+			goqu.On(goqu.I("song.group_id").Eq(goqu.I("group.id"))),
+		).
+		Select(
+			goqu.I("song.*"),
+			goqu.I("group.name").As("group"),
+		).
+		Order(goqu.I("song.release_date").Asc(), goqu.I("song.id").Asc())
+	query = withSongFilter(query, filters)
+
+	sql, args, err := query.ToSQL()
+	if err != nil {
+		return errors.Wrap(err, "failed to build query")
+	}
+
+	// Cursors only live inside a transaction.
+	return repo.trManager.Do(ctx, func(ctx context.Context) error {
+		tr := repo.getter.DefaultTrOrDB(ctx, repo.pool)
+		if err := postgres.SetLocalStatementTimeout(ctx, tr); err != nil {
+			return err
+		}
+		if _, err := tr.Exec(ctx, "DECLARE song_cursor NO SCROLL CURSOR FOR "+sql, args...); err != nil {
+			return errors.Wrap(err, "failed to declare cursor")
+		}
+
+		fetch := fmt.Sprintf("FETCH FORWARD %d FROM song_cursor", fetchSize)
+		for {
+			songs := make([]model.Song, 0, fetchSize)
+			if err := pgxscan.Select(ctx, tr, &songs, fetch); err != nil {
+				return errors.Wrap(err, "failed to fetch from cursor")
+			}
+
+			for _, song := range songs {
+				if err := fn(song); err != nil {
+					return err
+				}
+			}
+
+			if uint(len(songs)) < fetchSize {
+				break
+			}
+		}
+
+		if _, err := tr.Exec(ctx, "CLOSE song_cursor"); err != nil {
+			return errors.Wrap(err, "failed to close cursor")
+		}
+
+		return nil
+	})
+}
+
 func (repo *songRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Song, error) {
 	query := goqu.Dialect("postgres").
 		From("song").
@@ -143,29 +226,50 @@ func (repo *songRepository) GetByNameAndGroup(ctx context.Context, group, name s
 	return &song, nil
 }
 
-func (repo *songRepository) Count(ctx context.Context, filters *model.SongFilter) (uint, error) {
+// GetExisting returns the group and name of the given songs that are in the
+// library already.
+func (repo *songRepository) GetExisting(ctx context.Context, songs []model.Song) ([]model.Song, error) {
+	existing := make([]model.Song, 0)
+	if len(songs) == 0 {
+		return existing, nil
+	}
+
+	conditions := make([]exp.Expression, 0, len(songs))
+	for _, song := range songs {
+		conditions = append(conditions, goqu.Ex{"group.name": song.Group, "song.song": song.Song})
+	}
+
 	query := goqu.Dialect("postgres").
 		From("song").
-		Select(goqu.COUNT("*").As("total"))
+		Join(
+			goqu.T("group"),
+			goqu.On(goqu.I("song.group_id").Eq(goqu.I("group.id"))),
+		).
+		Select(
+			goqu.I("group.name").As("group"),
+			goqu.I("song.song"),
+		).
+		Where(goqu.Or(conditions...))
 
-	if filters != nil {
-		if filters.GroupID != uuid.Nil {
-			query = query.Where(goqu.Ex{"group_id": filters.GroupID})
-		}
-		if filters.Song != nil {
-			query = query.Where(goqu.Ex{"song": goqu.Op{"ilike": "%" + *filters.Song + "%"}})
-		}
-		if filters.Text != nil {
-			query = query.Where(goqu.Ex{"text": goqu.Op{"ilike": "%" + *filters.Text + "%"}})
-		}
-		if filters.Link != nil {
-			query = query.Where(goqu.Ex{"link": goqu.Op{"ilike": "%" + *filters.Link + "%"}})
-		}
-		if filters.ReleaseDate != nil {
-			query = query.Where(goqu.I("release_date").Eq(*filters.ReleaseDate))
-		}
+	sql, args, err := query.ToSQL()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build query")
+	}
+
+	tr := repo.getter.DefaultTrOrDB(ctx, repo.pool)
+	if err = pgxscan.Select(ctx, tr, &existing, sql, args...); err != nil {
+		return nil, errors.Wrap(err, "failed to execute query")
 	}
 
+	return existing, nil
+}
+
+func (repo *songRepository) Count(ctx context.Context, filters *model.SongFilter) (uint, error) {
+	query := goqu.Dialect("postgres").
+		From("song").
+		Select(goqu.COUNT("*").As("total"))
+	query = withSongFilter(query, filters)
+
 	sql, args, err := query.ToSQL()
 	if err != nil {
 		return 0, errors.Wrap(err, "failed to build query")
@@ -210,11 +314,107 @@ func (repo *songRepository) Create(ctx context.Context, entity model.Song) (*mod
 	return &song, nil
 }
 
+// CopyNew bulk inserts the songs that are not in their group yet and returns
+// the ids of the inserted ones.
+func (repo *songRepository) CopyNew(ctx context.Context, entities []model.Song) ([]uuid.UUID, error) {
+	columns := []string{
+		"id",
+		"group_id",
+		"song",
+		"text",
+		"link",
+		"release_date",
+		"release_date_precision",
+		"provenance",
+	}
+
+	ids := make([]uuid.UUID, 0, len(entities))
+	err := repo.trManager.Do(ctx, func(ctx context.Context) error {
+		tr := repo.getter.DefaultTrOrDB(ctx, repo.pool)
+		if err := postgres.SetLocalStatementTimeout(ctx, tr); err != nil {
+			return err
+		}
+		if _, err := tr.Exec(ctx, "CREATE TEMPORARY TABLE song_import (LIKE song INCLUDING DEFAULTS) ON COMMIT DROP"); err != nil {
+			return errors.Wrap(err, "failed to create staging table")
+		}
+
+		_, err := tr.CopyFrom(ctx, pgx.Identifier{"song_import"}, columns, pgx.CopyFromSlice(len(entities), func(i int) ([]any, error) {
+			song := entities[i]
+			id := song.ID
+			if id == uuid.Nil {
+				id = uuid.New()
+			}
+			precision := song.ReleaseDatePrecision
+			if precision == "" {
+				precision = partial_date.PrecisionDay
+			}
+			provenance := song.Provenance
+			if provenance == nil {
+				provenance = model.SongProvenance{}
+			}
+
+			return []any{
+				id,
+				song.GroupID,
+				song.Song,
+				song.Text,
+				song.Link,
+				song.ReleaseDate,
+				string(precision),
+				provenance,
+			}, nil
+		}))
+		if err != nil {
+			return errors.Wrap(err, "failed to copy songs")
+		}
+
+		cols := make([]any, 0, len(columns))
+		for _, column := range columns {
+			cols = append(cols, column)
+		}
+		existing := goqu.Dialect("postgres").
+			From("song").
+			Select(goqu.L("1")).
+			Where(
+				goqu.I("song.group_id").Eq(goqu.I("imported.group_id")),
+				goqu.I("song.song").Eq(goqu.I("imported.song")),
+			)
+		query := goqu.Dialect("postgres").
+			Insert("song").
+			Cols(cols...).
+			FromQuery(goqu.Dialect("postgres").
+				From(goqu.T("song_import").As("imported")).
+				Select(cols...).
+				Where(goqu.L("NOT EXISTS ?", existing))).
+			Returning("id")
+
+		sql, args, err := query.ToSQL()
+		if err != nil {
+			return errors.Wrap(err, "failed to build query")
+		}
+
+		if err = pgxscan.Select(ctx, tr, &ids, sql, args...); err != nil {
+			return errors.Wrap(err, "failed to insert songs")
+		}
+
+		if _, err = tr.Exec(ctx, "DROP TABLE song_import"); err != nil {
+			return errors.Wrap(err, "failed to drop staging table")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
 func (repo *songRepository) Update(ctx context.Context, entity model.Song) (*model.Song, error) {
 	var song model.Song
 	err := repo.trManager.Do(ctx, func(ctx context.Context) error {
 		tr := repo.getter.DefaultTrOrDB(ctx, repo.pool)
-		record := converter.ToRecordFromSong(entity)
+		record := converter.ToUpdateRecordFromSong(entity)
 		record["updated_at"] = goqu.L("CURRENT_TIMESTAMP")
 
 		query := goqu.Dialect("postgres").
@@ -244,6 +444,55 @@ func (repo *songRepository) Update(ctx context.Context, entity model.Song) (*mod
 	return &song, nil
 }
 
+// MoveToGroup returns how many songs were moved and the names of those
+// skipped because the target group has them already.
+func (repo *songRepository) MoveToGroup(ctx context.Context, fromGroupID, toGroupID uuid.UUID) (int64, []string, error) {
+	duplicate := goqu.Dialect("postgres").
+		From(goqu.T("song").As("target")).
+		Select(goqu.L("1")).
+		Where(
+			goqu.I("target.group_id").Eq(toGroupID),
+			goqu.I("target.song").Eq(goqu.I("song.song")),
+		)
+
+	skippedQuery := goqu.Dialect("postgres").
+		From("song").
+		Select("song").
+		Where(goqu.Ex{"group_id": fromGroupID}, goqu.L("EXISTS ?", duplicate)).
+		Order(goqu.I("song").Asc())
+
+	sql, args, err := skippedQuery.ToSQL()
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "failed to build query")
+	}
+
+	skipped := make([]string, 0)
+	tr := repo.getter.DefaultTrOrDB(ctx, repo.pool)
+	if err = pgxscan.Select(ctx, tr, &skipped, sql, args...); err != nil {
+		return 0, nil, errors.Wrap(err, "failed to execute query")
+	}
+
+	query := goqu.Dialect("postgres").
+		Update("song").
+		Set(goqu.Record{
+			"group_id":   toGroupID,
+			"updated_at": goqu.L("CURRENT_TIMESTAMP"),
+		}).
+		Where(goqu.Ex{"group_id": fromGroupID}, goqu.L("NOT EXISTS ?", duplicate))
+
+	sql, args, err = query.ToSQL()
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "failed to build query")
+	}
+
+	tag, err := tr.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "failed to execute query")
+	}
+
+	return tag.RowsAffected(), skipped, nil
+}
+
 func (repo *songRepository) Delete(ctx context.Context, song model.Song) error {
 	query := goqu.Dialect("postgres").
 		Delete("song").
@@ -261,3 +510,30 @@ func (repo *songRepository) Delete(ctx context.Context, song model.Song) error {
 
 	return nil
 }
+
+func withSongFilter(query *goqu.SelectDataset, filters *model.SongFilter) *goqu.SelectDataset {
+	if filters == nil {
+		return query
+	}
+
+	if filters.GroupID != uuid.Nil {
+		query = query.Where(goqu.Ex{"group_id": filters.GroupID})
+	}
+	if filters.Song != nil {
+		query = query.Where(goqu.Ex{"song": goqu.Op{"ilike": "%" + *filters.Song + "%"}})
+	}
+	if filters.Text != nil {
+		query = query.Where(goqu.Ex{"text": goqu.Op{"ilike": "%" + *filters.Text + "%"}})
+	}
+	if filters.Link != nil {
+		query = query.Where(goqu.Ex{"link": goqu.Op{"ilike": "%" + *filters.Link + "%"}})
+	}
+	if filters.ReleaseDate != nil {
+		query = query.Where(
+			goqu.I("release_date").Gte(filters.ReleaseDate.Time),
+			goqu.I("release_date").Lt(filters.ReleaseDate.End()),
+		)
+	}
+
+	return query
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/google/uuid"
+	"reflect"
+	"testing"
+)
+
+var (
+	testSourceGroupID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	testTargetGroupID = uuid.MustParse("00000000-0000-0000-0000-000000000002")
+)
+
+// newTestSongRepository returns a repository on empty group and song tables,
+// with a source and a target group holding the given songs.
+func newTestSongRepository(t *testing.T, source, target []string) *songRepository {
+	t.Helper()
+
+	pool := testPool(t)
+	ctx := context.Background()
+	if _, err := pool.Exec(ctx, `TRUNCATE "group" CASCADE`); err != nil {
+		t.Fatal(err)
+	}
+	_, err := pool.Exec(ctx, `INSERT INTO "group" (id, name) VALUES ($1, 'Source'), ($2, 'Target')`,
+		testSourceGroupID, testTargetGroupID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for groupID, songs := range map[uuid.UUID][]string{testSourceGroupID: source, testTargetGroupID: target} {
+		for _, song := range songs {
+			_, err = pool.Exec(ctx, `INSERT INTO song (group_id, song, text, link, release_date) VALUES ($1, $2, '', '', '2006-07-16')`,
+				groupID, song)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	return NewSongRepository(pool, trmpgx.DefaultCtxGetter, manager.Must(trmpgx.NewDefaultFactory(pool)))
+}
+
+func TestSongRepository_MoveToGroup(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      []string
+		target      []string
+		wantMoved   int64
+		wantSkipped []string
+		wantTarget  int
+	}{
+		{name: "empty source", target: []string{"a"}, wantSkipped: []string{}, wantTarget: 1},
+		{name: "no collisions", source: []string{"a", "b"}, target: []string{"c"}, wantMoved: 2, wantSkipped: []string{}, wantTarget: 3},
+		{name: "collisions skipped", source: []string{"a", "b", "c"}, target: []string{"c", "a"}, wantMoved: 1, wantSkipped: []string{"a", "c"}, wantTarget: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestSongRepository(t, tt.source, tt.target)
+			ctx := context.Background()
+
+			moved, skipped, err := repo.MoveToGroup(ctx, testSourceGroupID, testTargetGroupID)
+			if err != nil {
+				t.Fatalf("MoveToGroup() error = %v", err)
+			}
+			if moved != tt.wantMoved || !reflect.DeepEqual(skipped, tt.wantSkipped) {
+				t.Errorf("MoveToGroup() = %d, %v, want %d, %v", moved, skipped, tt.wantMoved, tt.wantSkipped)
+			}
+
+			var total int
+			err = repo.pool.QueryRow(ctx, "SELECT COUNT(*) FROM song WHERE group_id = $1", testTargetGroupID).Scan(&total)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != tt.wantTarget {
+				t.Errorf("songs in the target group = %d, want %d", total, tt.wantTarget)
+			}
+		})
+	}
+}
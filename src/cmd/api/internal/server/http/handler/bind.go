@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"song-library-api/src/cmd/api/internal/server/http/validator"
+)
+
+// BindQuery binds the query parameters whatever the request method, Echo
+// binds them only for GET, DELETE and HEAD requests.
+func BindQuery(ctx echo.Context, request any) error {
+	if err := (&echo.DefaultBinder{}).BindQueryParams(ctx, request); err != nil {
+		return validator.BindError(ctx, err)
+	}
+	return nil
+}
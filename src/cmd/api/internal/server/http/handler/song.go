@@ -0,0 +1,157 @@
+// Package handler holds the song handling shared by the API versions.
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	http2 "net/http"
+	"song-library-api/src/cmd/api/internal/exporter"
+	"song-library-api/src/cmd/api/internal/importer"
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/cmd/api/internal/server/http/validator"
+	"song-library-api/src/cmd/api/internal/service"
+	"song-library-api/src/pkg/partial_date"
+)
+
+// exportFlushInterval is the number of songs written between flushes.
+const exportFlushInterval = 100
+
+type SongHandler struct {
+	songService  service.SongService
+	groupService service.GroupService
+}
+
+func NewSongHandler(
+	songService service.SongService,
+	groupService service.GroupService) *SongHandler {
+	return &SongHandler{
+		songService:  songService,
+		groupService: groupService,
+	}
+}
+
+// SongFilter has the fields of the filter requests of every version, which
+// convert to it.
+type SongFilter struct {
+	Group       *string
+	Song        *string
+	Text        *string
+	Link        *string
+	ReleaseDate *string
+}
+
+// ToSongFilter looks the group up and parses the release date with the date
+// format of the version.
+func (h *SongHandler) ToSongFilter(ctx echo.Context,
+	request SongFilter,
+	parseDate func(value string) (partial_date.Date, error)) (*model.SongFilter, error) {
+	filters := &model.SongFilter{
+		GroupID: uuid.Nil,
+		Song:    request.Song,
+		Text:    request.Text,
+		Link:    request.Link,
+	}
+
+	if request.Group != nil {
+		group, err := h.groupService.GetByName(ctx.Request().Context(), *request.Group)
+		if err != nil {
+			return nil, err
+		}
+		filters.GroupID = group.ID
+	}
+
+	if request.ReleaseDate != nil {
+		releaseDate, err := parseDate(*request.ReleaseDate)
+		if err != nil {
+			return nil, validator.NewFieldError(ctx, "releaseDate", validator.RuleDate)
+		}
+		filters.ReleaseDate = &releaseDate
+	}
+
+	return filters, nil
+}
+
+// Export streams the songs matching the filters as a file download in the
+// given format.
+func (h *SongHandler) Export(ctx echo.Context, format string, formatDate exporter.DateFormat, filters *model.SongFilter) error {
+	exportFormat, err := exporter.ParseFormat(format)
+	if err != nil {
+		return err
+	}
+
+	response := ctx.Response()
+	writer, err := exporter.NewWriter(response, exportFormat, formatDate)
+	if err != nil {
+		return err
+	}
+
+	response.Header().Set(echo.HeaderContentType, exportFormat.ContentType())
+	response.Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=%q", exportFormat.Filename("songs")))
+
+	// The status is sent with the first song, so errors before it still get
+	// a regular error response.
+	written := 0
+	err = h.songService.Export(ctx.Request().Context(), filters, func(song model.Song) error {
+		if written == 0 {
+			response.WriteHeader(http2.StatusOK)
+		}
+		if err := writer.Write(song); err != nil {
+			return err
+		}
+		written++
+		if written%exportFlushInterval == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			response.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if written == 0 {
+		response.WriteHeader(http2.StatusOK)
+	}
+
+	return writer.Close()
+}
+
+// ReadImport reads the rows of the uploaded file. An empty format is detected
+// from the file name, mapping is an optional JSON object of field to column.
+func (h *SongHandler) ReadImport(ctx echo.Context, format, mapping string) ([]model.SongImportRow, error) {
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		return nil, validator.NewFieldError(ctx, "file", validator.RuleRequired)
+	}
+
+	var importFormat importer.Format
+	if format != "" {
+		importFormat, err = importer.ParseFormat(format)
+	} else {
+		importFormat, err = importer.FormatFromFilename(file.Filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := importer.Mapping{}
+	if mapping != "" {
+		if err = json.Unmarshal([]byte(mapping), &columns); err != nil {
+			return nil, validator.NewFieldError(ctx, "mapping", validator.RuleMapping)
+		}
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open uploaded file")
+	}
+	defer src.Close()
+
+	return importer.Read(src, importFormat, columns)
+}
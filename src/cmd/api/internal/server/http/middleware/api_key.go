@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+	"song-library-api/src/cmd/api/internal/logging"
+	"song-library-api/src/cmd/api/internal/model"
+	"strings"
+)
+
+const (
+	HeaderAPIKey = "X-API-Key"
+
+	apiKeyContextKey = "apiKey"
+)
+
+// APIKeyMiddleware identifies clients sending an API key in the X-API-Key
+// header or as a bearer token. Requests without a key stay anonymous,
+// requests with an unknown or revoked key are rejected.
+func APIKeyMiddleware(authenticate func(ctx context.Context, key string) (*model.APIKey, error)) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := requestAPIKey(c.Request().Header)
+			if key == "" {
+				return next(c)
+			}
+
+			request := c.Request()
+			apiKey, err := authenticate(request.Context(), key)
+			if err != nil {
+				return err
+			}
+
+			c.Set(apiKeyContextKey, apiKey)
+			c.SetRequest(request.WithContext(logging.With(request.Context(),
+				"user", apiKey.Name,
+				"apiKeyId", apiKey.ID)))
+
+			return next(c)
+		}
+	}
+}
+
+// RequireAPIKeyMiddleware rejects anonymous requests. It goes after
+// APIKeyMiddleware, which has rejected unknown keys already.
+func RequireAPIKeyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if APIKeyFromContext(c) == nil {
+			return errors.Wrap(model.ErrUnauthorized, "api key is required")
+		}
+		return next(c)
+	}
+}
+
+// APIKeyFromContext returns the key the client authenticated with, nil for
+// anonymous requests.
+func APIKeyFromContext(c echo.Context) *model.APIKey {
+	apiKey, _ := c.Get(apiKeyContextKey).(*model.APIKey)
+	return apiKey
+}
+
+func requestAPIKey(header http.Header) string {
+	if key := header.Get(HeaderAPIKey); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(header.Get(echo.HeaderAuthorization), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
@@ -4,25 +4,112 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 	"net/http"
+	"song-library-api/src/cmd/api/internal/db/postgres"
 	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/cmd/api/internal/server/http/v2/view"
+	"song-library-api/src/cmd/api/internal/server/http/validator"
+	"strings"
 )
 
+const (
+	mimeApplicationProblemJSON = "application/problem+json"
+	headerAcceptLanguage       = "Accept-Language"
+	headerContentLanguage      = "Content-Language"
+)
+
+// ErrorHandlerMiddleware renders errors of the v1 API as RFC 7807 problem
+// details. Field errors are translated following Accept-Language.
 func ErrorHandlerMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		err := next(c)
 
-		status := http.StatusInternalServerError
 		if err != nil {
-			switch {
-			case errors.Is(err, model.ErrBadRequest), errors.Is(err, echo.ErrBadRequest):
-				status = http.StatusBadRequest
-			case errors.Is(err, model.ErrNotFound):
-				status = http.StatusNotFound
+			// A streamed response cannot be replaced by an error body.
+			if c.Response().Committed {
+				return err
+			}
+
+			status, detail := errorStatus(err)
+			problem := model.Problem{
+				Type:     model.ProblemTypeBlank,
+				Title:    http.StatusText(status),
+				Status:   status,
+				Detail:   detail,
+				Instance: c.Request().URL.Path,
+				Message:  detail,
+			}
+			if fieldErrs := fieldErrors(c, err); fieldErrs != nil {
+				problem.Type = model.ProblemTypeValidation
+				problem.Title = "Request validation failed"
+				problem.Errors = fieldErrs
 			}
 
-			return c.JSON(status, model.APIError{Message: err.Error()})
+			c.Response().Header().Set(echo.HeaderContentType, mimeApplicationProblemJSON)
+			return c.JSON(status, problem)
 		}
 
 		return nil
 	}
 }
+
+// ErrorEnvelopeMiddleware renders errors of the v2 API as view.ErrorResponse.
+func ErrorEnvelopeMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		err := next(c)
+		if err == nil || c.Response().Committed {
+			return err
+		}
+
+		status, message := errorStatus(err)
+		return c.JSON(status, view.ErrorResponse{Error: view.Error{
+			Status:    status,
+			Code:      strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_"),
+			Message:   message,
+			RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+			Errors:    fieldErrors(c, err),
+		}})
+	}
+}
+
+// errorStatus maps err to a status code and message. Echo errors, such as
+// 404 for unknown routes or 413 for large bodies, keep their own status.
+func errorStatus(err error) (int, string) {
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		message := strings.ToLower(http.StatusText(httpErr.Code))
+		if text, ok := httpErr.Message.(string); ok {
+			message = text
+		}
+		return httpErr.Code, message
+	}
+
+	switch {
+	case errors.Is(err, model.ErrBadRequest):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, model.ErrNotFound):
+		return http.StatusNotFound, err.Error()
+	case errors.Is(err, model.ErrUnauthorized):
+		return http.StatusUnauthorized, err.Error()
+	case errors.Is(err, model.ErrTooManyRequests):
+		return http.StatusTooManyRequests, err.Error()
+	case postgres.IsTimeout(err):
+		return http.StatusServiceUnavailable, err.Error()
+	default:
+		return http.StatusInternalServerError, err.Error()
+	}
+}
+
+// fieldErrors translates the failures of a validation error, nil for other
+// errors, and marks the response as varying by language.
+func fieldErrors(c echo.Context, err error) []model.FieldError {
+	var validationErr *validator.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil
+	}
+
+	fieldErrs, locale := validationErr.FieldErrors(c.Request().Header.Get(headerAcceptLanguage))
+	header := c.Response().Header()
+	header.Set(headerContentLanguage, locale)
+	header.Add(echo.HeaderVary, headerAcceptLanguage)
+	return fieldErrs
+}
@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/cmd/api/internal/server/http/v2/view"
+	"song-library-api/src/cmd/api/internal/server/http/validator"
+	"testing"
+)
+
+var errorTests = []struct {
+	name        string
+	err         func(c echo.Context) error
+	wantStatus  int
+	wantCode    string
+	wantMessage string
+	wantErrors  []model.FieldError
+}{
+	{
+		name:        "bad request",
+		err:         func(echo.Context) error { return errors.Wrap(model.ErrBadRequest, "page is invalid") },
+		wantStatus:  http.StatusBadRequest,
+		wantCode:    "bad_request",
+		wantMessage: "page is invalid: bad request",
+	},
+	{
+		name:        "not found",
+		err:         func(echo.Context) error { return errors.Wrap(model.ErrNotFound, "song") },
+		wantStatus:  http.StatusNotFound,
+		wantCode:    "not_found",
+		wantMessage: "song: not found",
+	},
+	{
+		name:        "unauthorized",
+		err:         func(echo.Context) error { return errors.Wrap(model.ErrUnauthorized, "api key is required") },
+		wantStatus:  http.StatusUnauthorized,
+		wantCode:    "unauthorized",
+		wantMessage: "api key is required: unauthorized",
+	},
+	{
+		name:        "too many requests",
+		err:         func(echo.Context) error { return errors.Wrap(model.ErrTooManyRequests, "rate limit exceeded") },
+		wantStatus:  http.StatusTooManyRequests,
+		wantCode:    "too_many_requests",
+		wantMessage: "rate limit exceeded: too many requests",
+	},
+	{
+		name:        "timeout",
+		err:         func(echo.Context) error { return errors.Wrap(context.DeadlineExceeded, "failed to execute query") },
+		wantStatus:  http.StatusServiceUnavailable,
+		wantCode:    "service_unavailable",
+		wantMessage: "failed to execute query: context deadline exceeded",
+	},
+	{
+		name:        "echo error without message",
+		err:         func(echo.Context) error { return &echo.HTTPError{Code: http.StatusNotFound} },
+		wantStatus:  http.StatusNotFound,
+		wantCode:    "not_found",
+		wantMessage: "not found",
+	},
+	{
+		name: "echo error with message",
+		err: func(echo.Context) error {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "body is too large")
+		},
+		wantStatus:  http.StatusRequestEntityTooLarge,
+		wantCode:    "request_entity_too_large",
+		wantMessage: "body is too large",
+	},
+	{
+		name:        "unknown error",
+		err:         func(echo.Context) error { return errors.New("boom") },
+		wantStatus:  http.StatusInternalServerError,
+		wantCode:    "internal_server_error",
+		wantMessage: "boom",
+	},
+	{
+		name:        "validation error",
+		err:         func(c echo.Context) error { return validator.NewFieldError(c, "file", validator.RuleRequired) },
+		wantStatus:  http.StatusBadRequest,
+		wantCode:    "bad_request",
+		wantMessage: "invalid file (required)",
+		wantErrors:  []model.FieldError{{Field: "file", Rule: "required", Message: "file — обязательное поле"}},
+	},
+}
+
+func serveError(middleware echo.MiddlewareFunc, err func(c echo.Context) error) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.Validator = validator.NewRequestValidator()
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/songs", nil)
+	request.Header.Set(headerAcceptLanguage, "ru")
+	recorder := httptest.NewRecorder()
+
+	c := e.NewContext(request, recorder)
+	c.Response().Header().Set(echo.HeaderXRequestID, "request-1")
+	if handlerErr := middleware(func(c echo.Context) error { return err(c) })(c); handlerErr != nil {
+		e.HTTPErrorHandler(handlerErr, c)
+	}
+	return recorder
+}
+
+func TestErrorHandlerMiddleware(t *testing.T) {
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serveError(ErrorHandlerMiddleware, tt.err)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if got := recorder.Header().Get(echo.HeaderContentType); got != mimeApplicationProblemJSON {
+				t.Errorf("Content-Type = %q, want %q", got, mimeApplicationProblemJSON)
+			}
+
+			var problem model.Problem
+			if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("failed to decode problem: %v", err)
+			}
+
+			want := model.Problem{
+				Type:     model.ProblemTypeBlank,
+				Title:    http.StatusText(tt.wantStatus),
+				Status:   tt.wantStatus,
+				Detail:   tt.wantMessage,
+				Instance: "/api/v1/songs",
+				Message:  tt.wantMessage,
+			}
+			if tt.wantErrors != nil {
+				want.Type = model.ProblemTypeValidation
+				want.Title = "Request validation failed"
+				want.Errors = tt.wantErrors
+			}
+			if !reflect.DeepEqual(problem, want) {
+				t.Errorf("problem = %+v, want %+v", problem, want)
+			}
+			checkContentLanguage(t, recorder, tt.wantErrors != nil)
+		})
+	}
+}
+
+func TestErrorEnvelopeMiddleware(t *testing.T) {
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serveError(ErrorEnvelopeMiddleware, tt.err)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+
+			var response view.ErrorResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode error response: %v", err)
+			}
+
+			want := view.ErrorResponse{Error: view.Error{
+				Status:    tt.wantStatus,
+				Code:      tt.wantCode,
+				Message:   tt.wantMessage,
+				RequestID: "request-1",
+				Errors:    tt.wantErrors,
+			}}
+			if !reflect.DeepEqual(response, want) {
+				t.Errorf("response = %+v, want %+v", response, want)
+			}
+			checkContentLanguage(t, recorder, tt.wantErrors != nil)
+		})
+	}
+}
+
+func checkContentLanguage(t *testing.T, recorder *httptest.ResponseRecorder, translated bool) {
+	t.Helper()
+
+	want := ""
+	if translated {
+		want = "ru"
+	}
+	if got := recorder.Header().Get(headerContentLanguage); got != want {
+		t.Errorf("Content-Language = %q, want %q", got, want)
+	}
+}
+
+func TestErrorMiddlewareCommittedResponse(t *testing.T) {
+	for name, middleware := range map[string]echo.MiddlewareFunc{
+		"problem":  ErrorHandlerMiddleware,
+		"envelope": ErrorEnvelopeMiddleware,
+	} {
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			recorder := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), recorder)
+
+			streamErr := errors.New("stream failed")
+			err := middleware(func(c echo.Context) error {
+				c.Response().WriteHeader(http.StatusOK)
+				return streamErr
+			})(c)
+
+			if !errors.Is(err, streamErr) {
+				t.Errorf("error = %v, want the handler error", err)
+			}
+			if recorder.Code != http.StatusOK || recorder.Body.Len() != 0 {
+				t.Errorf("committed response was replaced: %d %q", recorder.Code, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// LoggerMiddleware writes an access log record per request with the log
+// context of the request, so that it carries the request ID.
+func LoggerMiddleware(logger *slog.Logger, skipper func(c echo.Context) bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if skipper != nil && skipper(c) {
+				return next(c)
+			}
+
+			start := time.Now()
+			err := next(c)
+			elapsed := time.Since(start)
+
+			status := c.Response().Status
+			if err != nil && !c.Response().Committed {
+				status = http.StatusInternalServerError
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					status = httpErr.Code
+				}
+			}
+
+			level := slog.LevelInfo
+			switch {
+			case status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case status >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			}
+
+			attrs := []slog.Attr{
+				slog.String("uri", c.Request().RequestURI),
+				slog.Int("status", status),
+				slog.Duration("elapsed", elapsed),
+				slog.String("remoteIp", c.RealIP()),
+				slog.Int64("bytesOut", c.Response().Size),
+			}
+			if err != nil {
+				attrs = append(attrs, slog.Any("error", err))
+			}
+			logger.LogAttrs(c.Request().Context(), level, "request", attrs...)
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"log/slog"
+	"math"
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/cmd/api/internal/ratelimit"
+	"strconv"
+)
+
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRateLimitPolicy    = "RateLimit-Policy"
+)
+
+// RateLimiter applies the budgets of the limiter per client. Clients are
+// told apart by API key and otherwise by IP address.
+type RateLimiter struct {
+	limiter *ratelimit.Limiter
+	logger  *slog.Logger
+}
+
+func NewRateLimiter(limiter *ratelimit.Limiter, logger *slog.Logger) *RateLimiter {
+	return &RateLimiter{
+		limiter: limiter,
+		logger:  logger,
+	}
+}
+
+// Limit counts every request of the route against the budget of the class.
+func (l *RateLimiter) Limit(class ratelimit.Class) echo.MiddlewareFunc {
+	return l.limit(func(echo.Context) ratelimit.Class {
+		return class
+	}, clientID)
+}
+
+// LimitSearch counts requests with any of the given query parameters as
+// searches and the others as reads.
+func (l *RateLimiter) LimitSearch(params ...string) echo.MiddlewareFunc {
+	return l.limit(func(c echo.Context) ratelimit.Class {
+		for _, param := range params {
+			if c.QueryParam(param) != "" {
+				return ratelimit.ClassSearch
+			}
+		}
+		return ratelimit.ClassRead
+	}, clientID)
+}
+
+// LimitAuth counts the requests carrying an API key against the auth budget
+// of their IP address. It goes before APIKeyMiddleware, so that unknown keys
+// are throttled before each of them costs a lookup.
+func (l *RateLimiter) LimitAuth() echo.MiddlewareFunc {
+	limit := l.limit(func(echo.Context) ratelimit.Class {
+		return ratelimit.ClassAuth
+	}, clientIP)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		limited := limit(next)
+		return func(c echo.Context) error {
+			if requestAPIKey(c.Request().Header) == "" {
+				return next(c)
+			}
+			return limited(c)
+		}
+	}
+}
+
+func (l *RateLimiter) limit(classify func(c echo.Context) ratelimit.Class, client func(c echo.Context) string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+
+			result, err := l.limiter.Allow(ctx, classify(c), client(c))
+			if err != nil {
+				// An unavailable store must not take the API down with it.
+				l.logger.WarnContext(ctx, "rate limit check failed", "error", err)
+				return next(c)
+			}
+			if result == nil {
+				return next(c)
+			}
+
+			reset := strconv.Itoa(int(math.Ceil(result.Reset.Seconds())))
+			header := c.Response().Header()
+			header.Set(HeaderRateLimitLimit, strconv.Itoa(result.Limit.Requests))
+			header.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+			header.Set(HeaderRateLimitReset, reset)
+			header.Set(HeaderRateLimitPolicy, fmt.Sprintf("%d;w=%d", result.Limit.Requests, int(result.Limit.Window.Seconds())))
+
+			if !result.Allowed {
+				header.Set(echo.HeaderRetryAfter, reset)
+				return errors.Wrap(model.ErrTooManyRequests, "rate limit exceeded")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func clientID(c echo.Context) string {
+	if apiKey := APIKeyFromContext(c); apiKey != nil {
+		return "key:" + apiKey.ID.String()
+	}
+	return clientIP(c)
+}
+
+func clientIP(c echo.Context) string {
+	return "ip:" + c.RealIP()
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/cmd/api/internal/ratelimit"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterLimitAuth(t *testing.T) {
+	limiter := NewRateLimiter(ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[ratelimit.Class]ratelimit.Limit{
+		ratelimit.ClassAuth: {Requests: 1, Window: time.Hour},
+	}), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	var lookups int
+	handler := limiter.LimitAuth()(func(c echo.Context) error {
+		lookups++
+		return nil
+	})
+
+	e := echo.New()
+	tests := []struct {
+		name        string
+		ip          string
+		header      string
+		value       string
+		wantLimited bool
+	}{
+		{name: "first key", ip: "10.0.0.1", header: HeaderAPIKey, value: "a"},
+		{name: "second key from the same ip", ip: "10.0.0.1", header: echo.HeaderAuthorization, value: "Bearer b", wantLimited: true},
+		{name: "key from another ip", ip: "10.0.0.2", header: HeaderAPIKey, value: "a"},
+		{name: "no key", ip: "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.RemoteAddr = tt.ip + ":1234"
+			if tt.header != "" {
+				request.Header.Set(tt.header, tt.value)
+			}
+			recorder := httptest.NewRecorder()
+
+			before := lookups
+			err := handler(e.NewContext(request, recorder))
+
+			if tt.wantLimited {
+				if !errors.Is(err, model.ErrTooManyRequests) {
+					t.Fatalf("LimitAuth() error = %v, want too many requests", err)
+				}
+				if lookups != before {
+					t.Error("limited request reached the next handler")
+				}
+				if recorder.Header().Get(echo.HeaderRetryAfter) == "" {
+					t.Errorf("%s header is not set", echo.HeaderRetryAfter)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("LimitAuth() error = %v", err)
+			}
+			if lookups != before+1 {
+				t.Error("request did not reach the next handler")
+			}
+			if limited := recorder.Header().Get(HeaderRateLimitLimit) != ""; limited != (tt.header != "") {
+				t.Errorf("%s header set = %t, want %t", HeaderRateLimitLimit, limited, tt.header != "")
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"song-library-api/src/cmd/api/internal/logging"
+)
+
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware keeps the X-Request-ID of the caller or generates one,
+// echoes it in the response and adds it to the log context together with
+// the route.
+func RequestIDMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		request := c.Request()
+
+		id := request.Header.Get(echo.HeaderXRequestID)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Response().Header().Set(echo.HeaderXRequestID, id)
+
+		ctx := logging.With(request.Context(),
+			"requestId", id,
+			"method", request.Method,
+			"route", c.Path())
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("request.id", id))
+		c.SetRequest(request.WithContext(ctx))
+
+		return next(c)
+	}
+}
+
+// validRequestID accepts printable ASCII only, so that a caller cannot
+// inject line breaks or oversized values into the logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
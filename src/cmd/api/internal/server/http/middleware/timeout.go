@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"time"
+)
+
+// TimeoutMiddleware sets a deadline on the request context. Queries and
+// upstream calls made with it are cancelled once the deadline passes, the
+// handler then fails with context.DeadlineExceeded. Zero sets no deadline.
+func TimeoutMiddleware(timeout time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if timeout <= 0 {
+			return next
+		}
+
+		return func(c echo.Context) error {
+			request := c.Request()
+			ctx, cancel := context.WithTimeout(request.Context(), timeout)
+			defer cancel()
+
+			c.SetRequest(request.WithContext(ctx))
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package route
+
+import (
+	"github.com/labstack/echo/v4"
+	v1 "song-library-api/src/cmd/api/internal/server/http/v1"
+)
+
+func InitHealthRoutes(group *echo.Group, controller *v1.HealthController) {
+	group.GET("/healthz", controller.Live)
+	group.GET("/readyz", controller.Ready)
+}
@@ -2,15 +2,50 @@ package route
 
 import (
 	"github.com/labstack/echo/v4"
-	v1 "song-library-api/src/cmd/api/internal/server/http/v1"
+	"song-library-api/src/cmd/api/internal/ratelimit"
+	"song-library-api/src/cmd/api/internal/server/http/middleware"
+	"time"
 )
 
-func InitSongRoutes(group *echo.Group, controller *v1.SongController) {
+// Timeouts are the request deadlines of the routes. Bulk applies to routes
+// that work through whole files or groups.
+type Timeouts struct {
+	Default time.Duration
+	Bulk    time.Duration
+}
+
+// SongController is implemented by the song controllers of every API
+// version, which share their routes.
+type SongController interface {
+	GetList(ctx echo.Context) error
+	Export(ctx echo.Context) error
+	GetText(ctx echo.Context) error
+	Create(ctx echo.Context) error
+	Import(ctx echo.Context) error
+	RefreshGroup(ctx echo.Context) error
+	Refresh(ctx echo.Context) error
+	Update(ctx echo.Context) error
+	Delete(ctx echo.Context) error
+}
+
+func InitSongRoutes(group *echo.Group, controller SongController, limiter *middleware.RateLimiter, timeouts Timeouts) {
 	g := group.Group("/songs")
 
-	g.GET("", controller.GetList)
-	g.GET("/:id/text", controller.GetText)
-	g.POST("", controller.Create)
-	g.PATCH("/:id", controller.Update)
-	g.DELETE("/:id", controller.Delete)
+	timeout := middleware.TimeoutMiddleware(timeouts.Default)
+	bulkTimeout := middleware.TimeoutMiddleware(timeouts.Bulk)
+
+	read := limiter.Limit(ratelimit.ClassRead)
+	write := limiter.Limit(ratelimit.ClassWrite)
+	// Filters are matched with ILIKE, which is what makes listing expensive.
+	search := limiter.LimitSearch("group", "song", "text", "link")
+
+	g.GET("", controller.GetList, timeout, search)
+	g.GET("/export", controller.Export, bulkTimeout, search)
+	g.GET("/:id/text", controller.GetText, timeout, read)
+	g.POST("", controller.Create, timeout, write)
+	g.POST("/import", controller.Import, bulkTimeout, write)
+	g.POST("/refresh", controller.RefreshGroup, bulkTimeout, write)
+	g.POST("/:id/refresh", controller.Refresh, timeout, write)
+	g.PATCH("/:id", controller.Update, timeout, write)
+	g.DELETE("/:id", controller.Delete, timeout, write)
 }
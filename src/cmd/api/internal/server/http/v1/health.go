@@ -0,0 +1,47 @@
+package v1
+
+import (
+	"github.com/labstack/echo/v4"
+	http2 "net/http"
+	"song-library-api/src/cmd/api/internal/health"
+)
+
+type HealthController struct {
+	checker *health.Checker
+}
+
+func NewHealthController(checker *health.Checker) *HealthController {
+	return &HealthController{
+		checker: checker,
+	}
+}
+
+// Live godoc
+// @Summary      Liveness probe
+// @Description  Reports that the process is up without checking dependencies
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object}  health.Report
+// @Router       /healthz [get]
+func (c *HealthController) Live(ctx echo.Context) error {
+	return ctx.JSON(http2.StatusOK, health.Report{Status: health.StatusOK, Checks: make([]health.CheckResult, 0)})
+}
+
+// Ready godoc
+// @Summary      Readiness probe
+// @Description  Checks the database, the migration state and optionally the music info service. Not ready during shutdown
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object}  health.Report
+// @Failure      503  {object}  health.Report  "Not ready"
+// @Router       /readyz [get]
+func (c *HealthController) Ready(ctx echo.Context) error {
+	report := c.checker.Ready(ctx.Request().Context())
+
+	status := http2.StatusOK
+	if !report.OK() {
+		status = http2.StatusServiceUnavailable
+	}
+
+	return ctx.JSON(status, report)
+}
@@ -0,0 +1,12 @@
+package song
+
+type ExportRequest struct {
+	FilterRequest
+	Format string `query:"format" validate:"omitempty,oneof=csv json ndjson"`
+}
+
+func (r *ExportRequest) SetDefaults() {
+	if r.Format == "" {
+		r.Format = "csv"
+	}
+}
@@ -0,0 +1,9 @@
+package song
+
+type FilterRequest struct {
+	Group       *string `query:"group"`
+	Song        *string `query:"song"`
+	Text        *string `query:"text"`
+	Link        *string `query:"link"`
+	ReleaseDate *string `query:"releaseDate"`
+}
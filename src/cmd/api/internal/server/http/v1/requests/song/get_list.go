@@ -1,13 +1,9 @@
 package song
 
 type GetListRequest struct {
-	Group       *string `query:"group"`
-	Song        *string `query:"song"`
-	Text        *string `query:"text"`
-	Link        *string `query:"link"`
-	ReleaseDate *string `query:"releaseDate"`
-	Page        uint    `query:"page" validate:"gte=0"`
-	PageSize    uint    `query:"pageSize" validate:"gte=0"`
+	FilterRequest
+	Page     uint `query:"page" validate:"gte=0"`
+	PageSize uint `query:"pageSize" validate:"gte=0"`
 }
 
 func (r *GetListRequest) SetDefaults() {
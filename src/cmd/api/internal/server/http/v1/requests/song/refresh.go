@@ -0,0 +1,15 @@
+package song
+
+import "github.com/google/uuid"
+
+type RefreshRequest struct {
+	ID     uuid.UUID `json:"id" validate:"required,uuid"`
+	DryRun bool      `query:"dryRun"`
+	Force  bool      `query:"force"`
+}
+
+type RefreshGroupRequest struct {
+	Group  string `query:"group" validate:"required,max=255"`
+	DryRun bool   `query:"dryRun"`
+	Force  bool   `query:"force"`
+}
@@ -2,13 +2,15 @@ package song
 
 import (
 	"github.com/google/uuid"
+	"song-library-api/src/cmd/api/internal/model"
 )
 
 type UpdateRequest struct {
-	ID          uuid.UUID `json:"id" validate:"required,uuid"`
-	Group       *string   `body:"group" validate:"max=255"`
-	Song        *string   `body:"song" validate:"max=255"`
-	Link        *string   `body:"link" validate:"max=2048"`
-	Text        *string   `body:"text" validate:"max=2048"`
-	ReleaseDate *string   `body:"releaseDate"`
+	ID          uuid.UUID              `json:"id" validate:"required,uuid"`
+	Group       model.Optional[string] `body:"group" validate:"max=255" swaggertype:"string"`
+	Song        model.Optional[string] `body:"song" validate:"max=255" swaggertype:"string"`
+	Link        model.Optional[string] `body:"link" validate:"max=2048" swaggertype:"string"`
+	Text        model.Optional[string] `body:"text" validate:"max=2048" swaggertype:"string"`
+	ReleaseDate model.Optional[string] `body:"releaseDate" swaggertype:"string"`
+	Refresh     bool                   `body:"refresh"`
 }
@@ -3,26 +3,27 @@ package v1
 import (
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
-	"github.com/pkg/errors"
 	http2 "net/http"
 	"song-library-api/src/cmd/api/internal/converter"
 	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/cmd/api/internal/server/http/handler"
 	"song-library-api/src/cmd/api/internal/server/http/v1/requests/song"
+	"song-library-api/src/cmd/api/internal/server/http/validator"
 	"song-library-api/src/cmd/api/internal/service"
-	"time"
+	"song-library-api/src/pkg/partial_date"
 )
 
 type SongController struct {
-	songService  service.SongService
-	groupService service.GroupService
+	songService service.SongService
+	handler     *handler.SongHandler
 }
 
 func NewSongController(
 	songService service.SongService,
 	groupService service.GroupService) *SongController {
 	return &SongController{
-		songService:  songService,
-		groupService: groupService,
+		songService: songService,
+		handler:     handler.NewSongHandler(songService, groupService),
 	}
 }
 
@@ -32,51 +33,36 @@ func NewSongController(
 // @Tags         Songs
 // @Accept       json
 // @Produce      json
+// @Security     ApiKeyAuth
 // @Param        group       query     string  false  "Filter by group name"
 // @Param        song        query     string  false  "Filter by song name"
 // @Param        text        query     string  false  "Filter by text"
 // @Param        link        query     string  false  "Filter by link"
-// @Param        releaseDate query     string  false  "Filter by release date (DD.MM.YYYY)"
+// @Param        releaseDate query     string  false  "Filter by release date (YYYY-MM-DD, DD.MM.YYYY, YYYY-MM or YYYY)"
 // @Param        page        query     int     false  "Page number (default: 1)"
 // @Param        pageSize    query     int     false  "Page size (default: 5)"
 // @Success      200         {object}  model.PaginatedList[model.SongView]
-// @Failure      400         {object}  model.APIError  "Bad request"
-// @Failure      500         {object}  model.APIError  "Internal server error"
+// @Failure      400         {object}  model.Problem  "Bad request"
+// @Failure      401         {object}  model.Problem  "Invalid API key"
+// @Failure      429         {object}  model.Problem  "Rate limit exceeded"
+// @Failure      500         {object}  model.Problem  "Internal server error"
 // @Router       /songs [get]
 func (c *SongController) GetList(ctx echo.Context) error {
 	var request song.GetListRequest
 	if err := ctx.Bind(&request); err != nil {
-		return model.ErrBadRequest
+		return validator.BindError(ctx, err)
 	}
 
 	request.SetDefaults()
 
 	if err := ctx.Validate(&request); err != nil {
-		return model.ErrBadRequest
+		return err
 	}
 
 	context := ctx.Request().Context()
-	filters := &model.SongFilter{
-		GroupID: uuid.Nil,
-		Song:    request.Song,
-		Text:    request.Text,
-		Link:    request.Link,
-	}
-
-	if request.Group != nil {
-		group, err := c.groupService.GetByName(context, *request.Group)
-		if err != nil {
-			return err
-		}
-		filters.GroupID = group.ID
-	}
-
-	if request.ReleaseDate != nil {
-		releaseDate, err := time.Parse("02.01.2006", *request.ReleaseDate)
-		if err != nil {
-			return errors.Wrap(err, "failed to parse release date")
-		}
-		filters.ReleaseDate = &releaseDate
+	filters, err := c.toSongFilter(ctx, request.FilterRequest)
+	if err != nil {
+		return err
 	}
 
 	songs, err := c.songService.GetSongs(context, filters, request.Page, request.PageSize)
@@ -92,36 +78,77 @@ func (c *SongController) GetList(ctx echo.Context) error {
 	})
 }
 
+// Export godoc
+// @Summary      Export songs
+// @Description  Streams every song matching the filters as a file download
+// @Tags         Songs
+// @Produce      text/csv,json,application/x-ndjson
+// @Security     ApiKeyAuth
+// @Param        format      query     string  false  "File format (csv, json, ndjson), default: csv"
+// @Param        group       query     string  false  "Filter by group name"
+// @Param        song        query     string  false  "Filter by song name"
+// @Param        text        query     string  false  "Filter by text"
+// @Param        link        query     string  false  "Filter by link"
+// @Param        releaseDate query     string  false  "Filter by release date (YYYY-MM-DD, DD.MM.YYYY, YYYY-MM or YYYY)"
+// @Success      200         {file}    file
+// @Failure      400         {object}  model.Problem  "Bad request"
+// @Failure      401         {object}  model.Problem  "Invalid API key"
+// @Failure      429         {object}  model.Problem  "Rate limit exceeded"
+// @Failure      500         {object}  model.Problem  "Internal server error"
+// @Router       /songs/export [get]
+func (c *SongController) Export(ctx echo.Context) error {
+	var request song.ExportRequest
+	if err := ctx.Bind(&request); err != nil {
+		return validator.BindError(ctx, err)
+	}
+
+	request.SetDefaults()
+
+	if err := ctx.Validate(&request); err != nil {
+		return err
+	}
+
+	filters, err := c.toSongFilter(ctx, request.FilterRequest)
+	if err != nil {
+		return err
+	}
+
+	return c.handler.Export(ctx, request.Format, partial_date.Date.String, filters)
+}
+
 // GetText godoc
 // @Summary      Get song text
 // @Description  Retrieves song text by song ID with optional pagination for verses
 // @Tags         Songs
 // @Accept       json
 // @Produce      json
+// @Security     ApiKeyAuth
 // @Param        id        path      string  true   "Song ID"
 // @Param        page      query     int     false  "Page number (default: 1)"
 // @Param        pageSize  query     int     false  "Page size (default: 1)"
 // @Success      200       {object}  []string
-// @Failure      400       {object}  model.APIError  "Bad request"
-// @Failure      404       {object}  model.APIError  "Song not found"
-// @Failure      500       {object}  model.APIError  "Internal server error"
+// @Failure      400       {object}  model.Problem  "Bad request"
+// @Failure      404       {object}  model.Problem  "Song not found"
+// @Failure      401       {object}  model.Problem  "Invalid API key"
+// @Failure      429       {object}  model.Problem  "Rate limit exceeded"
+// @Failure      500       {object}  model.Problem  "Internal server error"
 // @Router       /songs/{id}/text [get]
 func (c *SongController) GetText(ctx echo.Context) error {
 	var request song.GetTextRequest
 	if err := ctx.Bind(&request); err != nil {
-		return model.ErrBadRequest
+		return validator.BindError(ctx, err)
 	}
 
 	request.SetDefaults()
 
 	id, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
-		return model.ErrBadRequest
+		return validator.NewFieldError(ctx, "id", validator.RuleUUID)
 	}
 	request.ID = id
 
 	if err := ctx.Validate(&request); err != nil {
-		return model.ErrBadRequest
+		return err
 	}
 
 	context := ctx.Request().Context()
@@ -139,18 +166,21 @@ func (c *SongController) GetText(ctx echo.Context) error {
 // @Tags         Songs
 // @Accept       json
 // @Produce      json
+// @Security     ApiKeyAuth
 // @Param        song  body      song.CreateRequest  true  "Song data"
 // @Success      200   {object}  model.SongView
-// @Failure      400   {object}  model.APIError  "Bad request"
-// @Failure      500   {object}  model.APIError  "Internal server error"
+// @Failure      400   {object}  model.Problem  "Bad request"
+// @Failure      401   {object}  model.Problem  "Invalid API key"
+// @Failure      429   {object}  model.Problem  "Rate limit exceeded"
+// @Failure      500   {object}  model.Problem  "Internal server error"
 // @Router       /songs [post]
 func (c *SongController) Create(ctx echo.Context) error {
 	var request song.CreateRequest
 	if err := ctx.Bind(&request); err != nil {
-		return model.ErrBadRequest
+		return validator.BindError(ctx, err)
 	}
 	if err := ctx.Validate(&request); err != nil {
-		return model.ErrBadRequest
+		return err
 	}
 
 	context := ctx.Request().Context()
@@ -164,62 +194,194 @@ func (c *SongController) Create(ctx echo.Context) error {
 
 // Update godoc
 // @Summary      Update a song
-// @Description  Updates the details of an existing song
+// @Description  Updates the details of an existing song. Null text, link or release date resets the field from the music info service, an empty text or link clears it
 // @Tags         Songs
 // @Accept       json
 // @Produce      json
+// @Security     ApiKeyAuth
 // @Param        id    path      string          true   "Song ID"
 // @Param        song  body      song.UpdateRequest true "Updated song data"
 // @Success      200   {object}  model.SongView
-// @Failure      400   {object}  model.APIError  "Bad request"
-// @Failure      404   {object}  model.APIError  "Song not found"
-// @Failure      500   {object}  model.APIError  "Internal server error"
+// @Failure      400   {object}  model.Problem  "Bad request"
+// @Failure      404   {object}  model.Problem  "Song not found"
+// @Failure      401   {object}  model.Problem  "Invalid API key"
+// @Failure      429   {object}  model.Problem  "Rate limit exceeded"
+// @Failure      500   {object}  model.Problem  "Internal server error"
 // @Router       /songs/{id} [patch]
 func (c *SongController) Update(ctx echo.Context) error {
 	var request song.UpdateRequest
 	if err := ctx.Bind(&request); err != nil {
-		return model.ErrBadRequest
+		return validator.BindError(ctx, err)
+	}
+
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return validator.NewFieldError(ctx, "id", validator.RuleUUID)
+	}
+	request.ID = id
+
+	if err := ctx.Validate(&request); err != nil {
+		return err
+	}
+
+	patch := model.SongPatch{
+		ID:      request.ID,
+		Group:   request.Group,
+		Song:    request.Song,
+		Text:    request.Text,
+		Link:    request.Link,
+		Refresh: request.Refresh,
+	}
+
+	if request.ReleaseDate.Set {
+		if request.ReleaseDate.Null || request.ReleaseDate.Value == "" {
+			patch.ReleaseDate = model.Null[partial_date.Date]()
+		} else {
+			releaseDate, err := partial_date.Parse(request.ReleaseDate.Value)
+			if err != nil {
+				return validator.NewFieldError(ctx, "releaseDate", validator.RuleDate)
+			}
+			patch.ReleaseDate = model.Some(releaseDate)
+		}
+	}
+
+	context := ctx.Request().Context()
+	entity, err := c.songService.Edit(context, patch)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http2.StatusOK, converter.ToViewFromSong(*entity))
+}
+
+// Refresh godoc
+// @Summary      Refresh song metadata
+// @Description  Re-pulls text, link and release date from the music info service and reports the changed fields. Manually edited fields are kept unless forced
+// @Tags         Songs
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id      path      string  true   "Song ID"
+// @Param        dryRun  query     bool    false  "Only report changes without saving them"
+// @Param        force   query     bool    false  "Overwrite manually edited fields"
+// @Success      200     {object}  model.SongRefreshView
+// @Failure      400     {object}  model.Problem  "Bad request"
+// @Failure      404     {object}  model.Problem  "Song not found"
+// @Failure      401     {object}  model.Problem  "Invalid API key"
+// @Failure      429     {object}  model.Problem  "Rate limit exceeded"
+// @Failure      500     {object}  model.Problem  "Internal server error"
+// @Router       /songs/{id}/refresh [post]
+func (c *SongController) Refresh(ctx echo.Context) error {
+	var request song.RefreshRequest
+	if err := handler.BindQuery(ctx, &request); err != nil {
+		return err
 	}
 
 	id, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
-		return model.ErrBadRequest
+		return validator.NewFieldError(ctx, "id", validator.RuleUUID)
 	}
 	request.ID = id
 
 	if err := ctx.Validate(&request); err != nil {
-		return model.ErrBadRequest
+		return err
+	}
+
+	context := ctx.Request().Context()
+	refresh, err := c.songService.Refresh(context, request.ID, model.SongRefreshOptions{
+		DryRun: request.DryRun,
+		Force:  request.Force,
+	})
+	if err != nil {
+		return err
 	}
 
-	song := model.Song{ID: request.ID}
+	return ctx.JSON(http2.StatusOK, converter.ToViewFromSongRefresh(*refresh))
+}
+
+// RefreshGroup godoc
+// @Summary      Refresh metadata of a group's songs
+// @Description  Re-pulls metadata of every song of the group from the music info service and reports the changed fields
+// @Tags         Songs
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        group   query     string  true   "Group name"
+// @Param        dryRun  query     bool    false  "Only report changes without saving them"
+// @Param        force   query     bool    false  "Overwrite manually edited fields"
+// @Success      200     {object}  []model.SongRefreshView
+// @Failure      400     {object}  model.Problem  "Bad request"
+// @Failure      404     {object}  model.Problem  "Group not found"
+// @Failure      401     {object}  model.Problem  "Invalid API key"
+// @Failure      429     {object}  model.Problem  "Rate limit exceeded"
+// @Failure      500     {object}  model.Problem  "Internal server error"
+// @Router       /songs/refresh [post]
+func (c *SongController) RefreshGroup(ctx echo.Context) error {
+	var request song.RefreshGroupRequest
+	if err := handler.BindQuery(ctx, &request); err != nil {
+		return err
+	}
+	if err := ctx.Validate(&request); err != nil {
+		return err
+	}
 
-	if request.Group != nil {
-		song.Group = *request.Group
+	context := ctx.Request().Context()
+	refreshes, err := c.songService.RefreshGroup(context, request.Group, model.SongRefreshOptions{
+		DryRun: request.DryRun,
+		Force:  request.Force,
+	})
+	if err != nil {
+		return err
 	}
-	if request.Song != nil {
-		song.Song = *request.Song
+
+	return ctx.JSON(http2.StatusOK, converter.ToViewsFromSongRefresh(refreshes))
+}
+
+// Import godoc
+// @Summary      Import songs
+// @Description  Imports songs from a CSV, JSON or NDJSON file. CSV files need a header row. Invalid rows are reported and skipped, valid rows are saved in chunks
+// @Tags         Songs
+// @Accept       mpfd
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        file     formData  file    true   "CSV, JSON array or NDJSON file"
+// @Param        format   formData  string  false  "File format (csv, json, ndjson), detected from the file name by default"
+// @Param        mapping  formData  string  false  "JSON object mapping song fields to column names, e.g. {\"group\":\"Artist\"}"
+// @Param        dryRun   formData  bool    false  "Only validate rows without saving them"
+// @Param        enrich   formData  bool    false  "Fill missing text, link and release date from the music info service"
+// @Success      200      {object}  model.SongImport
+// @Failure      400      {object}  model.Problem  "Bad request"
+// @Failure      401      {object}  model.Problem  "Invalid API key"
+// @Failure      429      {object}  model.Problem  "Rate limit exceeded"
+// @Failure      500      {object}  model.Problem  "Internal server error"
+// @Router       /songs/import [post]
+func (c *SongController) Import(ctx echo.Context) error {
+	var request song.ImportRequest
+	if err := handler.BindQuery(ctx, &request); err != nil {
+		return err
 	}
-	if request.Text != nil {
-		song.Text = *request.Text
+	if err := ctx.Bind(&request); err != nil {
+		return validator.BindError(ctx, err)
 	}
-	if request.Link != nil {
-		song.Link = *request.Link
+	if err := ctx.Validate(&request); err != nil {
+		return err
 	}
-	if request.ReleaseDate != nil {
-		releaseDate, err := time.Parse("02.01.2006", *request.ReleaseDate)
-		if err != nil {
-			return errors.Wrap(err, "failed to parse release date")
-		}
-		song.ReleaseDate = releaseDate
+
+	rows, err := c.handler.ReadImport(ctx, request.Format, request.Mapping)
+	if err != nil {
+		return err
 	}
 
 	context := ctx.Request().Context()
-	entity, err := c.songService.Edit(context, song)
+	result, err := c.songService.Import(context, rows, model.SongImportOptions{
+		DryRun: request.DryRun,
+		Enrich: request.Enrich,
+	})
 	if err != nil {
 		return err
 	}
 
-	return ctx.JSON(http2.StatusOK, converter.ToViewFromSong(*entity))
+	return ctx.JSON(http2.StatusOK, result)
 }
 
 // Delete godoc
@@ -228,26 +390,29 @@ func (c *SongController) Update(ctx echo.Context) error {
 // @Tags         Songs
 // @Accept       json
 // @Produce      json
+// @Security     ApiKeyAuth
 // @Param        id    path      string  true   "Song ID"
 // @Success      200   {object}  model.SongView
-// @Failure      400   {object}  model.APIError  "Bad request"
-// @Failure      404   {object}  model.APIError  "Song not found"
-// @Failure      500   {object}  model.APIError  "Internal server error"
+// @Failure      400   {object}  model.Problem  "Bad request"
+// @Failure      404   {object}  model.Problem  "Song not found"
+// @Failure      401   {object}  model.Problem  "Invalid API key"
+// @Failure      429   {object}  model.Problem  "Rate limit exceeded"
+// @Failure      500   {object}  model.Problem  "Internal server error"
 // @Router       /songs/{id} [delete]
 func (c *SongController) Delete(ctx echo.Context) error {
 	var request song.DeleteRequest
 	if err := ctx.Bind(&request); err != nil {
-		return model.ErrBadRequest
+		return validator.BindError(ctx, err)
 	}
 
 	id, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
-		return model.ErrBadRequest
+		return validator.NewFieldError(ctx, "id", validator.RuleUUID)
 	}
 	request.ID = id
 
 	if err := ctx.Validate(&request); err != nil {
-		return model.ErrBadRequest
+		return err
 	}
 
 	context := ctx.Request().Context()
@@ -258,3 +423,7 @@ func (c *SongController) Delete(ctx echo.Context) error {
 
 	return ctx.JSON(http2.StatusOK, converter.ToViewFromSong(*entity))
 }
+
+func (c *SongController) toSongFilter(ctx echo.Context, request song.FilterRequest) (*model.SongFilter, error) {
+	return c.handler.ToSongFilter(ctx, handler.SongFilter(request), partial_date.Parse)
+}
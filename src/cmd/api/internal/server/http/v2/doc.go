@@ -0,0 +1,12 @@
+// Package v2 serves the second version of the API under /v2. Its JSON is
+// camelCase with ISO 8601 dates, songs embed their group, and every error
+// is a view.ErrorResponse. The v1 API stays unchanged.
+//
+// @title Song Library API
+// @version 2.0
+// @description Song Library API
+// @BasePath /v2
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+package v2
@@ -0,0 +1,15 @@
+package v2
+
+import (
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"song-library-api/src/cmd/api/internal/server/http/validator"
+)
+
+func parseID(ctx echo.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return uuid.Nil, validator.NewFieldError(ctx, "id", validator.RuleUUID)
+	}
+	return id, nil
+}
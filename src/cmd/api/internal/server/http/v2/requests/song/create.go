@@ -0,0 +1,6 @@
+package song
+
+type CreateRequest struct {
+	Group string `json:"group" validate:"required,max=255"`
+	Song  string `json:"song" validate:"required,max=255"`
+}
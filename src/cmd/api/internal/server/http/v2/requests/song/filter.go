@@ -0,0 +1,10 @@
+package song
+
+type FilterRequest struct {
+	Group *string `query:"group"`
+	Song  *string `query:"song"`
+	Text  *string `query:"text"`
+	Link  *string `query:"link"`
+	// ReleaseDate is YYYY-MM-DD, YYYY-MM or YYYY.
+	ReleaseDate *string `query:"releaseDate"`
+}
@@ -0,0 +1,16 @@
+package song
+
+type GetListRequest struct {
+	FilterRequest
+	Page     uint `query:"page" validate:"gte=0"`
+	PageSize uint `query:"pageSize" validate:"gte=0,lte=100"`
+}
+
+func (r *GetListRequest) SetDefaults() {
+	if r.PageSize == 0 {
+		r.PageSize = 5
+	}
+	if r.Page == 0 {
+		r.Page = 1
+	}
+}
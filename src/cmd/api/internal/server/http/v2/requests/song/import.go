@@ -0,0 +1,8 @@
+package song
+
+type ImportRequest struct {
+	Format  string `query:"format" form:"format" validate:"omitempty,oneof=csv json ndjson jsonl"`
+	Mapping string `query:"mapping" form:"mapping"`
+	DryRun  bool   `query:"dryRun" form:"dryRun"`
+	Enrich  bool   `query:"enrich" form:"enrich"`
+}
@@ -0,0 +1,12 @@
+package song
+
+type RefreshRequest struct {
+	DryRun bool `query:"dryRun"`
+	Force  bool `query:"force"`
+}
+
+type RefreshGroupRequest struct {
+	Group  string `query:"group" validate:"required,max=255"`
+	DryRun bool   `query:"dryRun"`
+	Force  bool   `query:"force"`
+}
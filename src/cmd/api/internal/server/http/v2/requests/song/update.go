@@ -0,0 +1,13 @@
+package song
+
+import "song-library-api/src/cmd/api/internal/model"
+
+type UpdateRequest struct {
+	Group model.Optional[string] `json:"group" validate:"max=255" swaggertype:"string"`
+	Song  model.Optional[string] `json:"song" validate:"max=255" swaggertype:"string"`
+	Link  model.Optional[string] `json:"link" validate:"max=2048" swaggertype:"string"`
+	Text  model.Optional[string] `json:"text" validate:"max=2048" swaggertype:"string"`
+	// ReleaseDate is YYYY-MM-DD, YYYY-MM or YYYY.
+	ReleaseDate model.Optional[string] `json:"releaseDate" swaggertype:"string"`
+	Refresh     bool                   `json:"refresh"`
+}
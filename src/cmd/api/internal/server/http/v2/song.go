@@ -0,0 +1,402 @@
+package v2
+
+import (
+	"github.com/labstack/echo/v4"
+	http2 "net/http"
+	"song-library-api/src/cmd/api/internal/converter"
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/cmd/api/internal/server/http/handler"
+	"song-library-api/src/cmd/api/internal/server/http/v2/requests/song"
+	"song-library-api/src/cmd/api/internal/server/http/validator"
+	"song-library-api/src/cmd/api/internal/service"
+	"song-library-api/src/pkg/partial_date"
+)
+
+type SongController struct {
+	songService service.SongService
+	handler     *handler.SongHandler
+}
+
+func NewSongController(
+	songService service.SongService,
+	groupService service.GroupService) *SongController {
+	return &SongController{
+		songService: songService,
+		handler:     handler.NewSongHandler(songService, groupService),
+	}
+}
+
+// GetList godoc
+// @Summary      Get list of songs
+// @Description  Retrieves a paginated list of songs with optional filters
+// @Tags         Songs
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        group       query     string  false  "Filter by group name"
+// @Param        song        query     string  false  "Filter by song name"
+// @Param        text        query     string  false  "Filter by text"
+// @Param        link        query     string  false  "Filter by link"
+// @Param        releaseDate query     string  false  "Filter by release date (YYYY-MM-DD, YYYY-MM or YYYY)"
+// @Param        page        query     int     false  "Page number (default: 1)"
+// @Param        pageSize    query     int     false  "Page size, at most 100 (default: 5)"
+// @Success      200         {object}  view.SongList
+// @Failure      400         {object}  view.ErrorResponse  "Bad request"
+// @Failure      401         {object}  view.ErrorResponse  "Invalid API key"
+// @Failure      404         {object}  view.ErrorResponse  "Group not found"
+// @Failure      429         {object}  view.ErrorResponse  "Rate limit exceeded"
+// @Failure      500         {object}  view.ErrorResponse  "Internal server error"
+// @Router       /songs [get]
+func (c *SongController) GetList(ctx echo.Context) error {
+	var request song.GetListRequest
+	if err := ctx.Bind(&request); err != nil {
+		return validator.BindError(ctx, err)
+	}
+
+	request.SetDefaults()
+
+	if err := ctx.Validate(&request); err != nil {
+		return err
+	}
+
+	context := ctx.Request().Context()
+	filters, err := c.toSongFilter(ctx, request.FilterRequest)
+	if err != nil {
+		return err
+	}
+
+	songs, err := c.songService.GetSongs(context, filters, request.Page, request.PageSize)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http2.StatusOK, converter.ToV2ViewFromSongList(*songs))
+}
+
+// Export godoc
+// @Summary      Export songs
+// @Description  Streams every song matching the filters as a file download
+// @Tags         Songs
+// @Produce      text/csv,json,application/x-ndjson
+// @Security     ApiKeyAuth
+// @Param        format      query     string  false  "File format (csv, json, ndjson), default: csv"
+// @Param        group       query     string  false  "Filter by group name"
+// @Param        song        query     string  false  "Filter by song name"
+// @Param        text        query     string  false  "Filter by text"
+// @Param        link        query     string  false  "Filter by link"
+// @Param        releaseDate query     string  false  "Filter by release date (YYYY-MM-DD, YYYY-MM or YYYY)"
+// @Success      200         {file}    file
+// @Failure      400         {object}  view.ErrorResponse  "Bad request"
+// @Failure      401         {object}  view.ErrorResponse  "Invalid API key"
+// @Failure      404         {object}  view.ErrorResponse  "Group not found"
+// @Failure      429         {object}  view.ErrorResponse  "Rate limit exceeded"
+// @Failure      500         {object}  view.ErrorResponse  "Internal server error"
+// @Router       /songs/export [get]
+func (c *SongController) Export(ctx echo.Context) error {
+	var request song.ExportRequest
+	if err := ctx.Bind(&request); err != nil {
+		return validator.BindError(ctx, err)
+	}
+
+	request.SetDefaults()
+
+	if err := ctx.Validate(&request); err != nil {
+		return err
+	}
+
+	filters, err := c.toSongFilter(ctx, request.FilterRequest)
+	if err != nil {
+		return err
+	}
+
+	return c.handler.Export(ctx, request.Format, partial_date.Date.ISO, filters)
+}
+
+// GetText godoc
+// @Summary      Get song text
+// @Description  Retrieves the verses of a song, paginated
+// @Tags         Songs
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id        path      string  true   "Song ID"
+// @Param        page      query     int     false  "Page number (default: 1)"
+// @Param        pageSize  query     int     false  "Verses per page, at most 100 (default: 1)"
+// @Success      200       {object}  view.SongText
+// @Failure      400       {object}  view.ErrorResponse  "Bad request"
+// @Failure      401       {object}  view.ErrorResponse  "Invalid API key"
+// @Failure      404       {object}  view.ErrorResponse  "Song not found"
+// @Failure      429       {object}  view.ErrorResponse  "Rate limit exceeded"
+// @Failure      500       {object}  view.ErrorResponse  "Internal server error"
+// @Router       /songs/{id}/text [get]
+func (c *SongController) GetText(ctx echo.Context) error {
+	var request song.GetTextRequest
+	if err := ctx.Bind(&request); err != nil {
+		return validator.BindError(ctx, err)
+	}
+
+	request.SetDefaults()
+
+	id, err := parseID(ctx)
+	if err != nil {
+		return err
+	}
+	request.ID = id
+
+	if err := ctx.Validate(&request); err != nil {
+		return err
+	}
+
+	context := ctx.Request().Context()
+	verses, err := c.songService.GetSongText(context, request.ID, request.Page, request.PageSize)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http2.StatusOK, converter.ToV2ViewFromSongText(*verses))
+}
+
+// Create godoc
+// @Summary      Create a new song
+// @Description  Adds a new song to the library, its details are looked up in the music info service
+// @Tags         Songs
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        song  body      song.CreateRequest  true  "Song data"
+// @Success      201   {object}  view.Song
+// @Failure      400   {object}  view.ErrorResponse  "Bad request"
+// @Failure      401   {object}  view.ErrorResponse  "Invalid API key"
+// @Failure      429   {object}  view.ErrorResponse  "Rate limit exceeded"
+// @Failure      500   {object}  view.ErrorResponse  "Internal server error"
+// @Router       /songs [post]
+func (c *SongController) Create(ctx echo.Context) error {
+	var request song.CreateRequest
+	if err := ctx.Bind(&request); err != nil {
+		return validator.BindError(ctx, err)
+	}
+	if err := ctx.Validate(&request); err != nil {
+		return err
+	}
+
+	context := ctx.Request().Context()
+	createdSong, err := c.songService.Add(context, request.Song, request.Group)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http2.StatusCreated, converter.ToV2ViewFromSong(*createdSong))
+}
+
+// Update godoc
+// @Summary      Update a song
+// @Description  Updates the details of an existing song. Null text, link or release date resets the field from the music info service, an empty text or link clears it
+// @Tags         Songs
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id    path      string              true  "Song ID"
+// @Param        song  body      song.UpdateRequest  true  "Updated song data"
+// @Success      200   {object}  view.Song
+// @Failure      400   {object}  view.ErrorResponse  "Bad request"
+// @Failure      401   {object}  view.ErrorResponse  "Invalid API key"
+// @Failure      404   {object}  view.ErrorResponse  "Song not found"
+// @Failure      429   {object}  view.ErrorResponse  "Rate limit exceeded"
+// @Failure      500   {object}  view.ErrorResponse  "Internal server error"
+// @Router       /songs/{id} [patch]
+func (c *SongController) Update(ctx echo.Context) error {
+	var request song.UpdateRequest
+	if err := ctx.Bind(&request); err != nil {
+		return validator.BindError(ctx, err)
+	}
+
+	id, err := parseID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Validate(&request); err != nil {
+		return err
+	}
+
+	patch := model.SongPatch{
+		ID:      id,
+		Group:   request.Group,
+		Song:    request.Song,
+		Text:    request.Text,
+		Link:    request.Link,
+		Refresh: request.Refresh,
+	}
+
+	if request.ReleaseDate.Set {
+		if request.ReleaseDate.Null || request.ReleaseDate.Value == "" {
+			patch.ReleaseDate = model.Null[partial_date.Date]()
+		} else {
+			releaseDate, err := partial_date.ParseISO(request.ReleaseDate.Value)
+			if err != nil {
+				return validator.NewFieldError(ctx, "releaseDate", validator.RuleDate)
+			}
+			patch.ReleaseDate = model.Some(releaseDate)
+		}
+	}
+
+	context := ctx.Request().Context()
+	entity, err := c.songService.Edit(context, patch)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http2.StatusOK, converter.ToV2ViewFromSong(*entity))
+}
+
+// Refresh godoc
+// @Summary      Refresh song metadata
+// @Description  Re-pulls text, link and release date from the music info service and reports the changed fields. Manually edited fields are kept unless forced
+// @Tags         Songs
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id      path      string  true   "Song ID"
+// @Param        dryRun  query     bool    false  "Only report changes without saving them"
+// @Param        force   query     bool    false  "Overwrite manually edited fields"
+// @Success      200     {object}  view.SongRefresh
+// @Failure      400     {object}  view.ErrorResponse  "Bad request"
+// @Failure      401     {object}  view.ErrorResponse  "Invalid API key"
+// @Failure      404     {object}  view.ErrorResponse  "Song not found"
+// @Failure      429     {object}  view.ErrorResponse  "Rate limit exceeded"
+// @Failure      500     {object}  view.ErrorResponse  "Internal server error"
+// @Router       /songs/{id}/refresh [post]
+func (c *SongController) Refresh(ctx echo.Context) error {
+	var request song.RefreshRequest
+	if err := handler.BindQuery(ctx, &request); err != nil {
+		return err
+	}
+
+	id, err := parseID(ctx)
+	if err != nil {
+		return err
+	}
+
+	context := ctx.Request().Context()
+	refresh, err := c.songService.Refresh(context, id, model.SongRefreshOptions{
+		DryRun: request.DryRun,
+		Force:  request.Force,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http2.StatusOK, converter.ToV2ViewFromSongRefresh(*refresh))
+}
+
+// RefreshGroup godoc
+// @Summary      Refresh metadata of a group's songs
+// @Description  Re-pulls metadata of every song of the group from the music info service and reports the changed fields
+// @Tags         Songs
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        group   query     string  true   "Group name"
+// @Param        dryRun  query     bool    false  "Only report changes without saving them"
+// @Param        force   query     bool    false  "Overwrite manually edited fields"
+// @Success      200     {object}  []view.SongRefresh
+// @Failure      400     {object}  view.ErrorResponse  "Bad request"
+// @Failure      401     {object}  view.ErrorResponse  "Invalid API key"
+// @Failure      404     {object}  view.ErrorResponse  "Group not found"
+// @Failure      429     {object}  view.ErrorResponse  "Rate limit exceeded"
+// @Failure      500     {object}  view.ErrorResponse  "Internal server error"
+// @Router       /songs/refresh [post]
+func (c *SongController) RefreshGroup(ctx echo.Context) error {
+	var request song.RefreshGroupRequest
+	if err := handler.BindQuery(ctx, &request); err != nil {
+		return err
+	}
+	if err := ctx.Validate(&request); err != nil {
+		return err
+	}
+
+	context := ctx.Request().Context()
+	refreshes, err := c.songService.RefreshGroup(context, request.Group, model.SongRefreshOptions{
+		DryRun: request.DryRun,
+		Force:  request.Force,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http2.StatusOK, converter.ToV2ViewsFromSongRefresh(refreshes))
+}
+
+// Import godoc
+// @Summary      Import songs
+// @Description  Imports songs from a CSV, JSON or NDJSON file. CSV files need a header row. Invalid rows are reported and skipped, valid rows are saved in chunks
+// @Tags         Songs
+// @Accept       mpfd
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        file     formData  file    true   "CSV, JSON array or NDJSON file"
+// @Param        format   formData  string  false  "File format (csv, json, ndjson), detected from the file name by default"
+// @Param        mapping  formData  string  false  "JSON object mapping song fields to column names, e.g. {\"group\":\"Artist\"}"
+// @Param        dryRun   formData  bool    false  "Only validate rows without saving them"
+// @Param        enrich   formData  bool    false  "Fill missing text, link and release date from the music info service"
+// @Success      200      {object}  view.SongImport
+// @Failure      400      {object}  view.ErrorResponse  "Bad request"
+// @Failure      401      {object}  view.ErrorResponse  "Invalid API key"
+// @Failure      429      {object}  view.ErrorResponse  "Rate limit exceeded"
+// @Failure      500      {object}  view.ErrorResponse  "Internal server error"
+// @Router       /songs/import [post]
+func (c *SongController) Import(ctx echo.Context) error {
+	var request song.ImportRequest
+	if err := handler.BindQuery(ctx, &request); err != nil {
+		return err
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return validator.BindError(ctx, err)
+	}
+	if err := ctx.Validate(&request); err != nil {
+		return err
+	}
+
+	rows, err := c.handler.ReadImport(ctx, request.Format, request.Mapping)
+	if err != nil {
+		return err
+	}
+
+	context := ctx.Request().Context()
+	result, err := c.songService.Import(context, rows, model.SongImportOptions{
+		DryRun: request.DryRun,
+		Enrich: request.Enrich,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http2.StatusOK, converter.ToV2ViewFromSongImport(*result))
+}
+
+// Delete godoc
+// @Summary      Delete a song
+// @Description  Deletes a song by its ID
+// @Tags         Songs
+// @Security     ApiKeyAuth
+// @Param        id    path      string  true   "Song ID"
+// @Success      204
+// @Failure      400   {object}  view.ErrorResponse  "Bad request"
+// @Failure      401   {object}  view.ErrorResponse  "Invalid API key"
+// @Failure      404   {object}  view.ErrorResponse  "Song not found"
+// @Failure      429   {object}  view.ErrorResponse  "Rate limit exceeded"
+// @Failure      500   {object}  view.ErrorResponse  "Internal server error"
+// @Router       /songs/{id} [delete]
+func (c *SongController) Delete(ctx echo.Context) error {
+	id, err := parseID(ctx)
+	if err != nil {
+		return err
+	}
+
+	context := ctx.Request().Context()
+	if _, err = c.songService.Delete(context, id); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http2.StatusNoContent)
+}
+
+func (c *SongController) toSongFilter(ctx echo.Context, request song.FilterRequest) (*model.SongFilter, error) {
+	return c.handler.ToSongFilter(ctx, handler.SongFilter(request), partial_date.ParseISO)
+}
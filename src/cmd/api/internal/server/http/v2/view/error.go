@@ -0,0 +1,20 @@
+package view
+
+import "song-library-api/src/cmd/api/internal/model"
+
+// ErrorResponse is the body of every failed v2 request.
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+type Error struct {
+	// Status repeats the HTTP status code.
+	Status int `json:"status"`
+	// Code is the snake_case status text, such as not_found, for clients to
+	// switch on.
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"requestId,omitempty"`
+	// Errors lists the invalid fields of a bad request.
+	Errors []model.FieldError `json:"errors,omitempty"`
+}
@@ -0,0 +1,17 @@
+package view
+
+type Pagination struct {
+	Page       uint `json:"page"`
+	PageSize   uint `json:"pageSize"`
+	TotalPages uint `json:"totalPages"`
+}
+
+type SongList struct {
+	Items []Song `json:"items"`
+	Pagination
+}
+
+type SongText struct {
+	Verses []string `json:"verses"`
+	Pagination
+}
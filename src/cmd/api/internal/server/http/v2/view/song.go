@@ -0,0 +1,65 @@
+package view
+
+import (
+	"github.com/google/uuid"
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/pkg/partial_date"
+	"time"
+)
+
+type Group struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+type Song struct {
+	ID    uuid.UUID `json:"id"`
+	Group Group     `json:"group"`
+	Song  string    `json:"song"`
+	Text  string    `json:"text"`
+	Link  string    `json:"link"`
+	// ReleaseDate is YYYY-MM-DD, YYYY-MM or YYYY depending on its precision,
+	// null if unknown.
+	ReleaseDate          *string                `json:"releaseDate"`
+	ReleaseDatePrecision partial_date.Precision `json:"releaseDatePrecision,omitempty" swaggertype:"string" enums:"day,month,year"`
+	Provenance           model.SongProvenance   `json:"provenance"`
+	CreatedAt            time.Time              `json:"createdAt"`
+	UpdatedAt            time.Time              `json:"updatedAt"`
+}
+
+type SongFieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+type SongRefresh struct {
+	Song    Song              `json:"song"`
+	Changes []SongFieldChange `json:"changes"`
+	Skipped []string          `json:"skipped"`
+	DryRun  bool              `json:"dryRun"`
+	Error   string            `json:"error,omitempty"`
+}
+
+type SongImportError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+type SongImportSkip struct {
+	Row    int    `json:"row"`
+	Group  string `json:"group"`
+	Song   string `json:"song"`
+	Reason string `json:"reason"`
+}
+
+type SongImport struct {
+	Total       int               `json:"total"`
+	Imported    int               `json:"imported"`
+	Failed      int               `json:"failed"`
+	Skipped     int               `json:"skipped"`
+	DryRun      bool              `json:"dryRun"`
+	Errors      []SongImportError `json:"errors"`
+	SkippedRows []SongImportSkip  `json:"skippedRows"`
+}
@@ -0,0 +1,143 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+	"song-library-api/src/cmd/api/internal/model"
+	"strings"
+)
+
+// Rules of failures found outside go-playground validation. Their messages
+// are added to the translators.
+const (
+	RuleRequired = "required"
+	RuleType     = "type"
+	RuleUUID     = "uuid"
+	RuleDate     = "date"
+	RuleMapping  = "mapping"
+)
+
+// ValidationError lists the request fields that failed validation. It is a
+// model.ErrBadRequest whose messages are translated on demand, so that they
+// can follow the Accept-Language of the request.
+type ValidationError struct {
+	translator *ut.UniversalTranslator
+	failures   []failure
+}
+
+type failure struct {
+	field string
+	rule  string
+	// translate reports false if the translator has no message for the rule.
+	translate func(translator ut.Translator) (string, bool)
+}
+
+func newValidationError(translator *ut.UniversalTranslator, fieldErrs validator.ValidationErrors) *ValidationError {
+	err := &ValidationError{translator: translator}
+	for _, fieldErr := range fieldErrs {
+		err.failures = append(err.failures, failure{
+			field: fieldErr.Field(),
+			rule:  fieldErr.Tag(),
+			translate: func(translator ut.Translator) (string, bool) {
+				message := fieldErr.Translate(translator)
+				return message, message != fieldErr.(error).Error()
+			},
+		})
+	}
+	return err
+}
+
+// NewFieldError reports a single field failing one of the Rule* rules.
+func NewFieldError(ctx echo.Context, field, rule string) error {
+	v, ok := ctx.Echo().Validator.(*requestValidator)
+	if !ok {
+		return errors.Wrapf(model.ErrBadRequest, "%s is invalid", field)
+	}
+
+	return &ValidationError{
+		translator: v.translator,
+		failures: []failure{{
+			field: field,
+			rule:  rule,
+			translate: func(translator ut.Translator) (string, bool) {
+				message, err := translator.T(rule, field)
+				return message, err == nil
+			},
+		}},
+	}
+}
+
+// BindError turns a failed echo bind into a bad request. A JSON value of the
+// wrong type is reported as a field error, other failures only by message.
+func BindError(ctx echo.Context, err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return NewFieldError(ctx, typeErr.Field, RuleType)
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		if message, ok := httpErr.Message.(string); ok {
+			return errors.Wrap(model.ErrBadRequest, message)
+		}
+	}
+	return errors.Wrap(model.ErrBadRequest, "malformed request")
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.failures))
+	for _, f := range e.failures {
+		fields = append(fields, f.field+" ("+f.rule+")")
+	}
+	return "invalid " + strings.Join(fields, ", ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == model.ErrBadRequest
+}
+
+// FieldErrors translates the failures into the first supported language of
+// the Accept-Language header, English by default, and returns that locale.
+// Messages missing in the language are given in English.
+func (e *ValidationError) FieldErrors(acceptLanguage string) ([]model.FieldError, string) {
+	translator, _ := e.translator.FindTranslator(acceptedLocales(acceptLanguage)...)
+	fallback := e.translator.GetFallback()
+
+	result := make([]model.FieldError, 0, len(e.failures))
+	for _, f := range e.failures {
+		message, ok := f.translate(translator)
+		if !ok {
+			message, ok = f.translate(fallback)
+		}
+		if !ok {
+			message = fmt.Sprintf("%s is invalid", f.field)
+		}
+
+		result = append(result, model.FieldError{
+			Field:   f.field,
+			Rule:    f.rule,
+			Message: message,
+		})
+	}
+	return result, translator.Locale()
+}
+
+// acceptedLocales returns the base languages of the header ordered by preference.
+func acceptedLocales(acceptLanguage string) []string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil {
+		return nil
+	}
+
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		base, _ := tag.Base()
+		result = append(result, base.String())
+	}
+	return result
+}
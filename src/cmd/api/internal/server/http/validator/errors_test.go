@@ -0,0 +1,186 @@
+package validator
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"song-library-api/src/cmd/api/internal/model"
+	"strings"
+	"testing"
+)
+
+type testRequest struct {
+	Name   string   `json:"name" validate:"required"`
+	Format string   `json:"format" validate:"omitempty,oneof=csv json"`
+	Tags   []string `json:"tags" validate:"max=2"`
+}
+
+func newTestContext(body string) echo.Context {
+	e := echo.New()
+	e.Validator = NewRequestValidator()
+
+	request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	return e.NewContext(request, httptest.NewRecorder())
+}
+
+func TestValidationErrorFieldErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        testRequest
+		acceptLanguage string
+		wantLocale     string
+		want           []model.FieldError
+	}{
+		{
+			name:       "english by default",
+			request:    testRequest{},
+			wantLocale: "en",
+			want:       []model.FieldError{{Field: "name", Rule: "required", Message: "name is a required field"}},
+		},
+		{
+			name:           "russian",
+			request:        testRequest{},
+			acceptLanguage: "ru-RU,ru;q=0.9",
+			wantLocale:     "ru",
+			want:           []model.FieldError{{Field: "name", Rule: "required", Message: "name — обязательное поле"}},
+		},
+		{
+			name:           "unsupported language falls back to english",
+			request:        testRequest{},
+			acceptLanguage: "de-DE",
+			wantLocale:     "en",
+			want:           []model.FieldError{{Field: "name", Rule: "required", Message: "name is a required field"}},
+		},
+		{
+			name:           "first supported language",
+			request:        testRequest{Name: "a", Format: "xml"},
+			acceptLanguage: "de, ru;q=0.8, en;q=0.5",
+			wantLocale:     "ru",
+			want:           []model.FieldError{{Field: "format", Rule: "oneof", Message: "format должно быть одним из [csv json]"}},
+		},
+		{
+			name:           "english size",
+			request:        testRequest{Name: "a", Tags: []string{"a", "b", "c"}},
+			acceptLanguage: "en-US",
+			wantLocale:     "en",
+			want:           []model.FieldError{{Field: "tags", Rule: "max", Message: "tags must contain at maximum 2 items"}},
+		},
+		{
+			name:           "russian size",
+			request:        testRequest{Name: "a", Tags: []string{"a", "b", "c"}},
+			acceptLanguage: "ru",
+			wantLocale:     "ru",
+			want:           []model.FieldError{{Field: "tags", Rule: "max", Message: "tags должно содержать не более 2 элементов"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newTestContext("").Validate(&tt.request)
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Validate() error = %v, want a ValidationError", err)
+			}
+			if !errors.Is(err, model.ErrBadRequest) {
+				t.Errorf("Validate() error is not a bad request")
+			}
+
+			got, locale := validationErr.FieldErrors(tt.acceptLanguage)
+			if locale != tt.wantLocale {
+				t.Errorf("FieldErrors() locale = %q, want %q", locale, tt.wantLocale)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FieldErrors() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFieldError(t *testing.T) {
+	tests := []struct {
+		name           string
+		field          string
+		rule           string
+		acceptLanguage string
+		want           string
+	}{
+		{name: "required", field: "file", rule: RuleRequired, want: "file is a required field"},
+		{name: "required russian", field: "file", rule: RuleRequired, acceptLanguage: "ru", want: "file — обязательное поле"},
+		{name: "date", field: "releaseDate", rule: RuleDate, want: "releaseDate must be a date such as YYYY-MM-DD, YYYY-MM or YYYY"},
+		{name: "mapping russian", field: "mapping", rule: RuleMapping, acceptLanguage: "ru",
+			want: "mapping должно быть JSON-объектом, сопоставляющим поля песни с колонками"},
+		{name: "unknown rule", field: "id", rule: "unknown", want: "id is invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewFieldError(newTestContext(""), tt.field, tt.rule)
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("NewFieldError() = %v, want a ValidationError", err)
+			}
+
+			got, _ := validationErr.FieldErrors(tt.acceptLanguage)
+			want := []model.FieldError{{Field: tt.field, Rule: tt.rule, Message: tt.want}}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("FieldErrors() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestBindError(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantField []model.FieldError
+	}{
+		{
+			name:      "value of the wrong type",
+			body:      `{"name": 1}`,
+			wantField: []model.FieldError{{Field: "name", Rule: RuleType, Message: "name has an invalid type"}},
+		},
+		{
+			name: "malformed body",
+			body: `{"name":`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(tt.body)
+
+			var request testRequest
+			bindErr := ctx.Bind(&request)
+			if bindErr == nil {
+				t.Fatal("Bind() succeeded")
+			}
+
+			err := BindError(ctx, bindErr)
+			if !errors.Is(err, model.ErrBadRequest) {
+				t.Fatalf("BindError() = %v, want a bad request", err)
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				if tt.wantField != nil {
+					t.Fatalf("BindError() = %v, want a ValidationError", err)
+				}
+				return
+			}
+			if tt.wantField == nil {
+				t.Fatalf("BindError() = %v, want no field errors", err)
+			}
+
+			got, _ := validationErr.FieldErrors("")
+			if !reflect.DeepEqual(got, tt.wantField) {
+				t.Errorf("FieldErrors() = %+v, want %+v", got, tt.wantField)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package validator
+
+import (
+	"reflect"
+	"song-library-api/src/cmd/api/internal/model"
+)
+
+// optionalValue validates the wrapped value of an optional PATCH field.
+func optionalValue(field reflect.Value) any {
+	if optional, ok := field.Interface().(model.Optional[string]); ok {
+		return optional.Value
+	}
+	return nil
+}
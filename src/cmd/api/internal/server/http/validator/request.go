@@ -1,19 +1,69 @@
 package validator
 
-import "github.com/go-playground/validator"
+import (
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator"
+	"log"
+	"reflect"
+	"song-library-api/src/cmd/api/internal/model"
+	"strings"
+)
+
+// nameTags name a field after its JSON body key, query parameter, form
+// field or path parameter, in this order.
+var nameTags = []string{"json", "body", "query", "form", "param"}
 
 type requestValidator struct {
-	validator *validator.Validate
+	validator  *validator.Validate
+	translator *ut.UniversalTranslator
 }
 
 func NewRequestValidator() *requestValidator {
 	v := validator.New()
 	_ = v.RegisterValidation("uuid", validateUUID)
+	v.RegisterCustomTypeFunc(optionalValue, model.Optional[string]{})
+	v.RegisterTagNameFunc(fieldName)
+
+	english := en.New()
+	translator := ut.New(english, english, ru.New())
+	if err := registerTranslations(v, translator); err != nil {
+		log.Fatal(err)
+	}
+
 	return &requestValidator{
-		validator: v,
+		validator:  v,
+		translator: translator,
 	}
 }
 
 func (v *requestValidator) Validate(i any) error {
-	return v.validator.Struct(i)
+	err := v.validator.Struct(i)
+	if fieldErrs, ok := err.(validator.ValidationErrors); ok {
+		return newValidationError(v.translator, fieldErrs)
+	}
+	return err
+}
+
+// registerTranslations adds the English and Russian messages of the rules,
+// both with the messages of bind failures.
+func registerTranslations(v *validator.Validate, translator *ut.UniversalTranslator) error {
+	english, _ := translator.GetTranslator("en")
+	if err := registerMessages(v, english, englishMessages); err != nil {
+		return err
+	}
+
+	russian, _ := translator.GetTranslator("ru")
+	return registerMessages(v, russian, russianMessages)
+}
+
+func fieldName(field reflect.StructField) string {
+	for _, tag := range nameTags {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return ""
 }
@@ -0,0 +1,169 @@
+package validator
+
+import (
+	"github.com/go-playground/locales"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// messages cover the rules used by the requests of the API and the rules
+// checked outside of validation. go-playground/validator v9 ships English
+// translations for another import path of the package only, and no Russian
+// ones.
+type messages struct {
+	rules map[string]string
+	// sizes phrase the size rules for strings, collections and numbers.
+	sizes map[string][3]string
+	// units are the characters and items counted by the size rules.
+	units map[string]map[locales.PluralRule]string
+}
+
+var englishMessages = messages{
+	rules: map[string]string{
+		RuleRequired: "{0} is a required field",
+		"oneof":      "{0} must be one of [{1}]",
+		RuleUUID:     "{0} must be a valid UUID",
+		RuleType:     "{0} has an invalid type",
+		RuleDate:     "{0} must be a date such as YYYY-MM-DD, YYYY-MM or YYYY",
+		RuleMapping:  "{0} must be a JSON object mapping song fields to column names",
+	},
+	sizes: map[string][3]string{
+		"len": {"{0} must be {1} in length", "{0} must contain {1}", "{0} must be equal to {1}"},
+		"min": {"{0} must be at least {1} in length", "{0} must contain at least {1}", "{0} must be {1} or greater"},
+		"max": {"{0} must be a maximum of {1} in length", "{0} must contain at maximum {1}", "{0} must be {1} or less"},
+		"gte": {"{0} must be at least {1} in length", "{0} must contain at least {1}", "{0} must be {1} or greater"},
+		"lte": {"{0} must be at maximum {1} in length", "{0} must contain at maximum {1}", "{0} must be {1} or less"},
+	},
+	units: map[string]map[locales.PluralRule]string{
+		"character": {
+			locales.PluralRuleOne:   "{0} character",
+			locales.PluralRuleOther: "{0} characters",
+		},
+		"item": {
+			locales.PluralRuleOne:   "{0} item",
+			locales.PluralRuleOther: "{0} items",
+		},
+	},
+}
+
+var russianMessages = messages{
+	rules: map[string]string{
+		RuleRequired: "{0} — обязательное поле",
+		"oneof":      "{0} должно быть одним из [{1}]",
+		RuleUUID:     "{0} должно быть корректным UUID",
+		RuleType:     "{0} имеет недопустимый тип",
+		RuleDate:     "{0} должно быть датой вида YYYY-MM-DD, YYYY-MM или YYYY",
+		RuleMapping:  "{0} должно быть JSON-объектом, сопоставляющим поля песни с колонками",
+	},
+	sizes: map[string][3]string{
+		"len": {"{0} должно содержать ровно {1}", "{0} должно содержать ровно {1}", "{0} должно быть равно {1}"},
+		"min": {"{0} должно содержать не менее {1}", "{0} должно содержать не менее {1}", "{0} должно быть не меньше {1}"},
+		"max": {"{0} должно содержать не более {1}", "{0} должно содержать не более {1}", "{0} должно быть не больше {1}"},
+		"gte": {"{0} должно содержать не менее {1}", "{0} должно содержать не менее {1}", "{0} должно быть больше или равно {1}"},
+		"lte": {"{0} должно содержать не более {1}", "{0} должно содержать не более {1}", "{0} должно быть меньше или равно {1}"},
+	},
+	// The units are in the genitive case that follows "не менее" and "не более".
+	units: map[string]map[locales.PluralRule]string{
+		"character": {
+			locales.PluralRuleOne:   "{0} символа",
+			locales.PluralRuleFew:   "{0} символов",
+			locales.PluralRuleMany:  "{0} символов",
+			locales.PluralRuleOther: "{0} символа",
+		},
+		"item": {
+			locales.PluralRuleOne:   "{0} элемента",
+			locales.PluralRuleFew:   "{0} элементов",
+			locales.PluralRuleMany:  "{0} элементов",
+			locales.PluralRuleOther: "{0} элемента",
+		},
+	},
+}
+
+func registerMessages(v *validator.Validate, translator ut.Translator, messages messages) error {
+	for key, message := range messages.rules {
+		if err := translator.Add(key, message, false); err != nil {
+			return err
+		}
+	}
+
+	for unit, rules := range messages.units {
+		for rule, message := range rules {
+			if err := translator.AddCardinal(unit, message, rule, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, tag := range []string{RuleRequired, "oneof", RuleUUID} {
+		err := v.RegisterTranslation(tag, translator, noopRegistration, func(translator ut.Translator, fe validator.FieldError) string {
+			message, err := translator.T(fe.Tag(), fe.Field(), fe.Param())
+			if err != nil {
+				return fe.(error).Error()
+			}
+			return message
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for tag, sizes := range messages.sizes {
+		register := func(translator ut.Translator) error {
+			for i, kind := range []string{"string", "items", "number"} {
+				if err := translator.Add(tag+"-"+kind, sizes[i], false); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := v.RegisterTranslation(tag, translator, register, translateSize); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// noopRegistration is used for the rule messages added by registerMessages.
+func noopRegistration(ut.Translator) error {
+	return nil
+}
+
+func translateSize(translator ut.Translator, fe validator.FieldError) string {
+	param := fe.Param()
+	number, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return fe.(error).Error()
+	}
+	var digits uint64
+	if _, fraction, ok := strings.Cut(param, "."); ok {
+		digits = uint64(len(fraction))
+	}
+
+	kind := fe.Kind()
+	if kind == reflect.Ptr {
+		kind = fe.Type().Elem().Kind()
+	}
+
+	var message string
+	switch kind {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		key, unit := "-items", "item"
+		if kind == reflect.String {
+			key, unit = "-string", "character"
+		}
+		var count string
+		if count, err = translator.C(unit, number, digits, translator.FmtNumber(number, digits)); err == nil {
+			message, err = translator.T(fe.Tag()+key, fe.Field(), count)
+		}
+	default:
+		message, err = translator.T(fe.Tag()+"-number", fe.Field(), translator.FmtNumber(number, digits))
+	}
+	if err != nil {
+		return fe.(error).Error()
+	}
+	return message
+}
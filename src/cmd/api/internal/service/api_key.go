@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"log/slog"
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/cmd/api/internal/repository"
+	"strings"
+)
+
+var _ APIKeyService = (*apiKeyService)(nil)
+
+const (
+	apiKeyPrefix       = "sl_"
+	apiKeyPrefixLength = len(apiKeyPrefix) + 8
+)
+
+type apiKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+	logger     *slog.Logger
+}
+
+func NewAPIKeyService(
+	apiKeyRepo repository.APIKeyRepository,
+	logger *slog.Logger) *apiKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		logger:     logger,
+	}
+}
+
+func (s *apiKeyService) Create(ctx context.Context, name string) (*model.CreatedAPIKey, error) {
+	if name == "" {
+		return nil, errors.Wrap(model.ErrBadRequest, "api key name is required")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, errors.Wrap(err, "failed to generate api key")
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	apiKey, err := s.apiKeyRepo.Create(ctx, model.APIKey{
+		Name:    name,
+		Prefix:  key[:apiKeyPrefixLength],
+		KeyHash: hashAPIKey(key),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create api key")
+	}
+
+	s.logger.InfoContext(ctx, "api key created", "id", apiKey.ID, "name", apiKey.Name, "prefix", apiKey.Prefix)
+
+	return &model.CreatedAPIKey{APIKey: *apiKey, Key: key}, nil
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, id uuid.UUID) (*model.APIKey, error) {
+	apiKey, err := s.apiKeyRepo.Revoke(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to revoke api key")
+	}
+
+	s.logger.InfoContext(ctx, "api key revoked", "id", apiKey.ID, "name", apiKey.Name, "prefix", apiKey.Prefix)
+
+	return apiKey, nil
+}
+
+// Authenticate returns the active API key with the given value.
+func (s *apiKeyService) Authenticate(ctx context.Context, key string) (*model.APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, errors.Wrap(model.ErrUnauthorized, "malformed api key")
+	}
+
+	apiKey, err := s.apiKeyRepo.GetByHash(ctx, hashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return nil, errors.Wrap(model.ErrUnauthorized, "unknown api key")
+		}
+		return nil, errors.Wrap(err, "failed to get api key")
+	}
+	if apiKey.RevokedAt != nil {
+		return nil, errors.Wrap(model.ErrUnauthorized, "api key is revoked")
+	}
+
+	return apiKey, nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
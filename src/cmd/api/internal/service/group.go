@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/pkg/errors"
 	"log/slog"
 	"song-library-api/src/cmd/api/internal/model"
 	"song-library-api/src/cmd/api/internal/repository"
@@ -12,16 +13,19 @@ var _ GroupService = (*groupService)(nil)
 
 type groupService struct {
 	groupRepo repository.GroupRepository
+	songRepo  repository.SongRepository
 	trManager *manager.Manager
 	logger    *slog.Logger
 }
 
 func NewGroupService(
 	groupRepo repository.GroupRepository,
+	songRepo repository.SongRepository,
 	trManager *manager.Manager,
 	logger *slog.Logger) *groupService {
 	return &groupService{
 		groupRepo: groupRepo,
+		songRepo:  songRepo,
 		trManager: trManager,
 		logger:    logger,
 	}
@@ -33,7 +37,56 @@ func (s *groupService) GetByName(ctx context.Context, group string) (*model.Grou
 		return nil, err
 	}
 
-	s.logger.Info("get group", "group", group)
+	s.logger.InfoContext(ctx, "get group", "group", group)
 
 	return groupDB, nil
 }
+
+// Merge moves the songs of the source groups to the target group and deletes
+// the source groups. Either all groups are merged or none. Songs the target
+// group has already are skipped and deleted with their source group.
+func (s *groupService) Merge(ctx context.Context, target string, sources []string) (*model.GroupMerge, error) {
+	merge := &model.GroupMerge{
+		Merged:       make([]string, 0, len(sources)),
+		SkippedSongs: make([]string, 0),
+	}
+	err := s.trManager.Do(ctx, func(ctx context.Context) error {
+		targetDB, err := s.groupRepo.GetByName(ctx, target)
+		if err != nil {
+			return errors.Wrap(err, "failed to get target group")
+		}
+		merge.Group = *targetDB
+
+		for _, source := range sources {
+			sourceDB, err := s.groupRepo.GetByName(ctx, source)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get group %q", source)
+			}
+			if sourceDB.ID == targetDB.ID {
+				return errors.Wrapf(model.ErrBadRequest, "group %q cannot be merged into itself", source)
+			}
+
+			moved, skipped, err := s.songRepo.MoveToGroup(ctx, sourceDB.ID, targetDB.ID)
+			if err != nil {
+				return errors.Wrapf(err, "failed to move songs of group %q", source)
+			}
+
+			if err = s.groupRepo.Delete(ctx, *sourceDB); err != nil {
+				return errors.Wrapf(err, "failed to delete group %q", source)
+			}
+
+			merge.Merged = append(merge.Merged, sourceDB.Name)
+			merge.MovedSongs += moved
+			merge.SkippedSongs = append(merge.SkippedSongs, skipped...)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to merge groups")
+	}
+
+	s.logger.InfoContext(ctx, "groups merged", "group", merge.Group.Name, "merged", merge.Merged, "songs", merge.MovedSongs, "skipped", len(merge.SkippedSongs))
+
+	return merge, nil
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"log/slog"
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/cmd/api/internal/repository"
+)
+
+var _ JobService = (*jobService)(nil)
+
+type jobService struct {
+	jobRepo     repository.JobRepository
+	maxAttempts int
+	logger      *slog.Logger
+}
+
+func NewJobService(
+	jobRepo repository.JobRepository,
+	maxAttempts int,
+	logger *slog.Logger) *jobService {
+	return &jobService{
+		jobRepo:     jobRepo,
+		maxAttempts: maxAttempts,
+		logger:      logger,
+	}
+}
+
+func (s *jobService) Enqueue(ctx context.Context, jobType string, payload any) (*model.Job, error) {
+	entity, err := s.newJob(jobType, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.jobRepo.Enqueue(ctx, entity)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to enqueue job")
+	}
+
+	s.logger.InfoContext(ctx, "job enqueued", "id", job.ID, "type", job.Type)
+
+	return job, nil
+}
+
+func (s *jobService) EnqueueMany(ctx context.Context, jobType string, payloads []any) (int64, error) {
+	entities := make([]model.Job, 0, len(payloads))
+	for _, payload := range payloads {
+		entity, err := s.newJob(jobType, payload)
+		if err != nil {
+			return 0, err
+		}
+		entities = append(entities, entity)
+	}
+
+	count, err := s.jobRepo.EnqueueMany(ctx, entities)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to enqueue jobs")
+	}
+
+	s.logger.InfoContext(ctx, "jobs enqueued", "type", jobType, "count", count)
+
+	return count, nil
+}
+
+func (s *jobService) newJob(jobType string, payload any) (model.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return model.Job{}, errors.Wrap(err, "failed to marshal job payload")
+	}
+
+	return model.Job{
+		Type:        jobType,
+		Payload:     data,
+		MaxAttempts: s.maxAttempts,
+	}, nil
+}
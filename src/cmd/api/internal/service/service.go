@@ -6,15 +6,38 @@ import (
 	"song-library-api/src/cmd/api/internal/model"
 )
 
+// Counter counts the songs and groups the services created. It is called once
+// the transaction has committed, so rolled back rows are not counted.
+type Counter interface {
+	SongsCreated(count int)
+	GroupsCreated(count int)
+}
+
 type SongService interface {
 	GetSongs(ctx context.Context, filters *model.SongFilter, page, pageSize uint) (*model.PaginatedList[model.Song], error)
+	Export(ctx context.Context, filters *model.SongFilter, fn func(song model.Song) error) error
 	GetSongText(ctx context.Context, id uuid.UUID, page, pageSize uint) (*model.PaginatedList[string], error)
 	GetByID(ctx context.Context, id uuid.UUID) (*model.Song, error)
 	Add(ctx context.Context, song, group string) (*model.Song, error)
-	Edit(ctx context.Context, song model.Song) (*model.Song, error)
+	Edit(ctx context.Context, patch model.SongPatch) (*model.Song, error)
+	Refresh(ctx context.Context, id uuid.UUID, opts model.SongRefreshOptions) (*model.SongRefresh, error)
+	RefreshGroup(ctx context.Context, group string, opts model.SongRefreshOptions) ([]model.SongRefresh, error)
+	Import(ctx context.Context, rows []model.SongImportRow, opts model.SongImportOptions) (*model.SongImport, error)
 	Delete(ctx context.Context, id uuid.UUID) (*model.Song, error)
 }
 
 type GroupService interface {
 	GetByName(ctx context.Context, group string) (*model.Group, error)
+	Merge(ctx context.Context, target string, sources []string) (*model.GroupMerge, error)
+}
+
+type JobService interface {
+	Enqueue(ctx context.Context, jobType string, payload any) (*model.Job, error)
+	EnqueueMany(ctx context.Context, jobType string, payloads []any) (int64, error)
+}
+
+type APIKeyService interface {
+	Create(ctx context.Context, name string) (*model.CreatedAPIKey, error)
+	Revoke(ctx context.Context, id uuid.UUID) (*model.APIKey, error)
+	Authenticate(ctx context.Context, key string) (*model.APIKey, error)
 }
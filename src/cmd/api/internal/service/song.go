@@ -10,6 +10,8 @@ import (
 	"song-library-api/src/cmd/api/internal/model"
 	"song-library-api/src/cmd/api/internal/repository"
 	"song-library-api/src/pkg/music_info_client"
+	musicinfo "song-library-api/src/pkg/music_info_client/model"
+	"song-library-api/src/pkg/partial_date"
 	"strings"
 	"time"
 )
@@ -19,6 +21,8 @@ var _ SongService = (*songService)(nil)
 type songService struct {
 	songRepo        repository.SongRepository
 	groupRepo       repository.GroupRepository
+	jobService      JobService
+	counter         Counter
 	musicInfoClient *music_info_client.MusicInfoClient
 	trManager       *manager.Manager
 	logger          *slog.Logger
@@ -27,12 +31,16 @@ type songService struct {
 func NewSongService(
 	songRepo repository.SongRepository,
 	groupRepo repository.GroupRepository,
+	jobService JobService,
+	counter Counter,
 	musicInfoClient *music_info_client.MusicInfoClient,
 	trManager *manager.Manager,
 	logger *slog.Logger) *songService {
 	return &songService{
 		songRepo:        songRepo,
 		groupRepo:       groupRepo,
+		jobService:      jobService,
+		counter:         counter,
 		musicInfoClient: musicInfoClient,
 		trManager:       trManager,
 		logger:          logger,
@@ -53,7 +61,7 @@ func (s *songService) GetSongs(ctx context.Context, filters *model.SongFilter, p
 
 	totalPages := uint(math.Ceil(float64(total) / float64(pageSize)))
 
-	s.logger.Info("get songs", "filters", filters)
+	s.logger.InfoContext(ctx, "get songs", "filters", filters)
 
 	return &model.PaginatedList[model.Song]{
 		Page:       page,
@@ -63,6 +71,24 @@ func (s *songService) GetSongs(ctx context.Context, filters *model.SongFilter, p
 	}, nil
 }
 
+// exportFetchSize is the number of songs read from the export cursor at once.
+const exportFetchSize = 500
+
+func (s *songService) Export(ctx context.Context, filters *model.SongFilter, fn func(song model.Song) error) error {
+	count := 0
+	err := s.songRepo.Stream(ctx, filters, exportFetchSize, func(song model.Song) error {
+		count++
+		return fn(song)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to export songs")
+	}
+
+	s.logger.InfoContext(ctx, "songs exported", "filters", filters, "count", count)
+
+	return nil
+}
+
 func (s *songService) GetSongText(ctx context.Context, id uuid.UUID, page, pageSize uint) (*model.PaginatedList[string], error) {
 	song, err := s.songRepo.GetByID(ctx, id)
 	if err != nil {
@@ -87,7 +113,7 @@ func (s *songService) GetSongText(ctx context.Context, id uuid.UUID, page, pageS
 		end = total
 	}
 
-	s.logger.Info("get song text", "id", id, "song", song, "group", song.Group)
+	s.logger.InfoContext(ctx, "get song text", "id", id, "song", song.Song, "group", song.Group, "page", page)
 
 	return &model.PaginatedList[string]{
 		Page:       page,
@@ -103,18 +129,25 @@ func (s *songService) GetByID(ctx context.Context, id uuid.UUID) (*model.Song, e
 		return nil, err
 	}
 
-	s.logger.Info("get song", "id", id, "song", song, "group", song.Group)
+	s.logger.InfoContext(ctx, "get song", "id", id, "song", song.Song, "group", song.Group)
 
 	return song, nil
 }
 
+// incomplete reports whether the song misses fields that the enrich job can
+// fill.
+func incomplete(song model.Song) bool {
+	return song.Text == "" || song.Link == ""
+}
+
 func (s *songService) Add(ctx context.Context, song, group string) (*model.Song, error) {
-	songDetail, err := s.musicInfoClient.GetSongInfo(ctx, group, song)
+	songDetail, err := s.getSongDetail(ctx, group, song)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get song detail")
 	}
 
 	var created *model.Song
+	groupCreated := false
 	err = s.trManager.Do(ctx, func(ctx context.Context) error {
 		groupDB, err := s.groupRepo.GetByName(ctx, group)
 		if err != nil && !errors.Is(err, model.ErrNotFound) {
@@ -126,32 +159,52 @@ func (s *songService) Add(ctx context.Context, song, group string) (*model.Song,
 			if err != nil {
 				return err
 			}
+			groupCreated = true
 
-			s.logger.Info("group created", "id", groupDB.ID, "group", groupDB.Name)
+			s.logger.InfoContext(ctx, "group created", "id", groupDB.ID, "group", groupDB.Name)
 		}
 
-		releaseDate, err := time.Parse("02.01.2006", songDetail.ReleaseDate)
+		releaseDate, err := partial_date.Parse(songDetail.ReleaseDate)
 		if err != nil {
 			return errors.Wrap(err, "failed to parse release date")
 		}
 
+		now := time.Now()
+		provenance := model.SongProvenance{}
+		provenance.SetUpstream(model.SongFieldText, model.ProviderMusicInfo, now)
+		provenance.SetUpstream(model.SongFieldLink, model.ProviderMusicInfo, now)
+		provenance.SetUpstream(model.SongFieldReleaseDate, model.ProviderMusicInfo, now)
+
 		created, err = s.songRepo.Create(ctx, model.Song{
-			GroupID:     groupDB.ID,
-			Song:        song,
-			Text:        songDetail.Text,
-			Link:        songDetail.Link,
-			ReleaseDate: releaseDate,
+			GroupID:              groupDB.ID,
+			Song:                 song,
+			Text:                 songDetail.Text,
+			Link:                 songDetail.Link,
+			ReleaseDate:          releaseDate.Time,
+			ReleaseDatePrecision: releaseDate.Precision,
+			Provenance:           provenance,
 		})
-
+		if err != nil {
+			return err
+		}
 		created.Group = groupDB.Name
 
+		if !incomplete(*created) {
+			return nil
+		}
+		_, err = s.jobService.Enqueue(ctx, model.JobTypeSongEnrich, model.SongEnrichPayload{SongID: created.ID})
 		return err
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to add song")
 	}
 
-	s.logger.Info("song created",
+	s.counter.SongsCreated(1)
+	if groupCreated {
+		s.counter.GroupsCreated(1)
+	}
+
+	s.logger.InfoContext(ctx, "song created",
 		"id", created.ID,
 		"group", created.Group,
 		"song", created.Song)
@@ -159,75 +212,272 @@ func (s *songService) Add(ctx context.Context, song, group string) (*model.Song,
 	return created, nil
 }
 
-func (s *songService) Edit(ctx context.Context, song model.Song) (*model.Song, error) {
-	songDB, err := s.songRepo.GetByID(ctx, song.ID)
+func (s *songService) Edit(ctx context.Context, patch model.SongPatch) (*model.Song, error) {
+	songDB, err := s.songRepo.GetByID(ctx, patch.ID)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get song")
 	}
 
-	songDetail, err := s.musicInfoClient.GetSongInfo(ctx, song.Group, song.Song)
+	now := time.Now()
+	song, lookup, err := patchSong(*songDB, patch, now)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get song detail")
+		return nil, err
+	}
+	if lookup {
+		songDetail, err := s.getSongDetail(ctx, song.Group, song.Song)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get song detail")
+		}
+
+		if _, err = applySongDetail(&song, songDetail, false, now); err != nil {
+			return nil, err
+		}
 	}
 
 	var updated *model.Song
+	groupCreated := false
 	err = s.trManager.Do(ctx, func(ctx context.Context) error {
-		groupDB, err := s.groupRepo.GetByName(ctx, song.Group)
-		if err != nil && !errors.Is(err, model.ErrNotFound) {
-			return errors.Wrap(err, "failed to get group by name")
-		}
+		if song.Group != songDB.Group {
+			groupDB, err := s.groupRepo.GetByName(ctx, song.Group)
+			if err != nil && !errors.Is(err, model.ErrNotFound) {
+				return errors.Wrap(err, "failed to get group by name")
+			}
 
-		if groupDB == nil {
-			groupDB, err = s.groupRepo.Create(ctx, model.Group{Name: song.Group})
-			if err != nil {
-				return err
+			if groupDB == nil {
+				groupDB, err = s.groupRepo.Create(ctx, model.Group{Name: song.Group})
+				if err != nil {
+					return err
+				}
+				groupCreated = true
+
+				s.logger.InfoContext(ctx, "group created", "id", groupDB.ID, "group", groupDB.Name)
 			}
 
-			s.logger.Info("group created", "id", groupDB.ID, "group", groupDB.Name)
+			song.GroupID = groupDB.ID
 		}
 
-		releaseDate, err := time.Parse("02.01.2006", songDetail.ReleaseDate)
+		updated, err = s.songRepo.Update(ctx, song)
 		if err != nil {
-			return errors.Wrap(err, "failed to parse release date")
+			return err
 		}
+		updated.Group = song.Group
 
-		if song.GroupID == uuid.Nil {
-			song.GroupID = groupDB.ID
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to edit song")
+	}
+
+	if groupCreated {
+		s.counter.GroupsCreated(1)
+	}
+
+	s.logger.InfoContext(ctx, "song updated",
+		"id", updated.ID,
+		"group", updated.Group,
+		"song", updated.Song)
+
+	return updated, nil
+}
+
+// patchSong applies the patch to a copy of the song. Explicit values become
+// manual, nulls drop the manual mark so that the field is reset from
+// upstream. It reports whether the song detail has to be looked up for that,
+// or because the song was renamed or a refresh was asked for.
+func patchSong(songDB model.Song, patch model.SongPatch, now time.Time) (model.Song, bool, error) {
+	song := songDB
+	song.Provenance = songDB.Provenance.Clone()
+
+	if patch.Group.Set {
+		if patch.Group.Null || patch.Group.Value == "" {
+			return model.Song{}, false, errors.Wrap(model.ErrBadRequest, "group can't be empty")
+		}
+		song.Group = patch.Group.Value
+	}
+	if patch.Song.Set {
+		if patch.Song.Null || patch.Song.Value == "" {
+			return model.Song{}, false, errors.Wrap(model.ErrBadRequest, "song can't be empty")
 		}
-		if song.Group == "" {
-			song.Group = songDB.Group
+		song.Song = patch.Song.Value
+	}
+
+	resets := 0
+	patchField := func(field string, set, null bool, apply func()) {
+		switch {
+		case !set:
+		case null:
+			delete(song.Provenance, field)
+			resets++
+		default:
+			apply()
+			song.Provenance.SetManual(field, now)
+		}
+	}
+	patchField(model.SongFieldText, patch.Text.Set, patch.Text.Null, func() { song.Text = patch.Text.Value })
+	patchField(model.SongFieldLink, patch.Link.Set, patch.Link.Null, func() { song.Link = patch.Link.Value })
+	patchField(model.SongFieldReleaseDate, patch.ReleaseDate.Set, patch.ReleaseDate.Null, func() {
+		song.SetReleaseDate(patch.ReleaseDate.Value)
+	})
+
+	identityChanged := song.Group != songDB.Group || song.Song != songDB.Song
+	return song, identityChanged || patch.Refresh || resets > 0, nil
+}
+
+func (s *songService) Refresh(ctx context.Context, id uuid.UUID, opts model.SongRefreshOptions) (*model.SongRefresh, error) {
+	songDB, err := s.songRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get song")
+	}
+
+	songDetail, err := s.getSongDetail(music_info_client.Revalidate(ctx), songDB.Group, songDB.Song)
+	return s.applyRefresh(ctx, *songDB, songDetail, err, opts)
+}
+
+func (s *songService) RefreshGroup(ctx context.Context, group string, opts model.SongRefreshOptions) ([]model.SongRefresh, error) {
+	groupDB, err := s.groupRepo.GetByName(ctx, group)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get group by name")
+	}
+
+	// Songs are paged by id, refreshing their release dates must not move
+	// them between pages.
+	const batchSize = 100
+	filters := &model.SongFilter{GroupID: groupDB.ID}
+	refreshes := make([]model.SongRefresh, 0)
+	for afterID := uuid.Nil; ; {
+		songs, err := s.songRepo.GetSongsAfter(ctx, filters, afterID, batchSize)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get songs")
 		}
-		if song.Song == "" {
-			song.Song = songDB.Song
+		if len(songs) == 0 {
+			break
 		}
-		if song.Text == "" {
-			song.Text = songDetail.Text
+		afterID = songs[len(songs)-1].ID
+
+		queries := make([]music_info_client.Query, 0, len(songs))
+		for _, song := range songs {
+			queries = append(queries, music_info_client.Query{Group: groupDB.Name, Song: song.Song})
 		}
-		if song.Link == "" {
-			song.Link = songDetail.Link
+		// A refresh must not be answered from the cache.
+		results := s.musicInfoClient.GetSongsInfo(music_info_client.Revalidate(ctx), queries)
+
+		for i, song := range songs {
+			song.Group = groupDB.Name
+			refresh, err := s.applyRefresh(ctx, song, results[i].SongDetail, mapSongDetailError(results[i].Err), opts)
+			if err != nil {
+				// One unavailable song must not abort the whole group.
+				refreshes = append(refreshes, model.SongRefresh{Song: song, DryRun: opts.DryRun, Error: err.Error()})
+				continue
+			}
+			refreshes = append(refreshes, *refresh)
 		}
-		if song.ReleaseDate.IsZero() {
-			song.ReleaseDate = releaseDate
+
+		if len(songs) < batchSize {
+			break
 		}
+	}
 
-		song.CreatedAt = songDB.CreatedAt
-		song.UpdatedAt = time.Now()
+	s.logger.InfoContext(ctx, "group refreshed", "group", groupDB.Name, "songs", len(refreshes), "dryRun", opts.DryRun)
 
-		updated, err = s.songRepo.Update(ctx, song)
-		updated.Group = groupDB.Name
+	return refreshes, nil
+}
 
-		return err
+func (s *songService) applyRefresh(ctx context.Context,
+	songDB model.Song,
+	songDetail *musicinfo.SongDetail,
+	err error,
+	opts model.SongRefreshOptions) (*model.SongRefresh, error) {
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get song detail")
+	}
+
+	refreshed := songDB
+	refreshed.Provenance = songDB.Provenance.Clone()
+	skipped, err := applySongDetail(&refreshed, songDetail, opts.Force, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	changes := diffSongs(songDB, refreshed)
+	if opts.DryRun || len(changes) == 0 {
+		return &model.SongRefresh{Song: refreshed, Changes: changes, Skipped: skipped, DryRun: opts.DryRun}, nil
+	}
+
+	updated, err := s.songRepo.Update(ctx, model.Song{
+		ID:                   songDB.ID,
+		Text:                 refreshed.Text,
+		Link:                 refreshed.Link,
+		ReleaseDate:          refreshed.ReleaseDate,
+		ReleaseDatePrecision: refreshed.ReleaseDatePrecision,
+		Provenance:           refreshed.Provenance,
 	})
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to edit song")
+		return nil, errors.Wrap(err, "failed to refresh song")
 	}
+	updated.Group = songDB.Group
 
-	s.logger.Info("song updated",
+	s.logger.InfoContext(ctx, "song refreshed",
 		"id", updated.ID,
 		"group", updated.Group,
-		"song", updated.Song)
+		"song", updated.Song,
+		"changes", len(changes),
+		"skipped", skipped)
 
-	return updated, nil
+	return &model.SongRefresh{Song: *updated, Changes: changes, Skipped: skipped}, nil
+}
+
+func (s *songService) getSongDetail(ctx context.Context, group, song string) (*musicinfo.SongDetail, error) {
+	songDetail, err := s.musicInfoClient.GetSongInfo(ctx, group, song)
+	return songDetail, mapSongDetailError(err)
+}
+
+func mapSongDetailError(err error) error {
+	if errors.Is(err, music_info_client.ErrSongNotFound) {
+		return errors.Wrap(model.ErrNotFound, "song not found in music info service")
+	}
+	return err
+}
+
+// applySongDetail overwrites song fields with upstream values. Manually edited
+// fields are kept unless forced and returned as skipped.
+func applySongDetail(song *model.Song, songDetail *musicinfo.SongDetail, force bool, now time.Time) ([]string, error) {
+	releaseDate, err := partial_date.Parse(songDetail.ReleaseDate)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse release date")
+	}
+
+	skipped := make([]string, 0)
+	overwrite := func(field string, apply func()) {
+		if !force && song.Provenance.IsManual(field) {
+			skipped = append(skipped, field)
+			return
+		}
+		apply()
+		song.Provenance.SetUpstream(field, model.ProviderMusicInfo, now)
+	}
+
+	overwrite(model.SongFieldText, func() { song.Text = songDetail.Text })
+	overwrite(model.SongFieldLink, func() { song.Link = songDetail.Link })
+	overwrite(model.SongFieldReleaseDate, func() { song.SetReleaseDate(releaseDate) })
+
+	return skipped, nil
+}
+
+func diffSongs(before, after model.Song) []model.SongFieldChange {
+	changes := make([]model.SongFieldChange, 0)
+	appendChange := func(field, old, new string) {
+		if old != new {
+			changes = append(changes, model.SongFieldChange{Field: field, Old: old, New: new})
+		}
+	}
+
+	appendChange(model.SongFieldText, before.Text, after.Text)
+	appendChange(model.SongFieldLink, before.Link, after.Link)
+	appendChange(model.SongFieldReleaseDate,
+		before.PartialReleaseDate().String(),
+		after.PartialReleaseDate().String())
+
+	return changes
 }
 
 func (s *songService) Delete(ctx context.Context, id uuid.UUID) (*model.Song, error) {
@@ -241,7 +491,7 @@ func (s *songService) Delete(ctx context.Context, id uuid.UUID) (*model.Song, er
 		return nil, errors.Wrap(err, "failed to delete song")
 	}
 
-	s.logger.Info("song deleted", "id", song.ID, "group", song.Group, "song", song.Song)
+	s.logger.InfoContext(ctx, "song deleted", "id", song.ID, "group", song.Group, "song", song.Song)
 
 	return song, nil
 }
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/pkg/music_info_client"
+	"song-library-api/src/pkg/partial_date"
+	"time"
+	"unicode/utf8"
+)
+
+const defaultImportChunkSize = 500
+
+// importedSong is a validated row waiting to be saved.
+type importedSong struct {
+	row    int
+	song   model.Song
+	failed bool
+	// enriched is set once the upstream song detail has been applied.
+	enriched bool
+}
+
+// needsEnrich reports whether the song was saved without upstream data and
+// misses fields that the enrich job can fill.
+func (imported importedSong) needsEnrich() bool {
+	return !imported.enriched && incomplete(imported.song)
+}
+
+// Import validates and saves rows in chunks. Each chunk is saved in its own
+// transaction, so a failed chunk does not roll back the chunks before it.
+// Invalid rows are reported and skipped without failing the import. Songs
+// repeated in the file or in the library already are skipped, so importing
+// the same file twice adds nothing the second time.
+func (s *songService) Import(ctx context.Context, rows []model.SongImportRow, opts model.SongImportOptions) (*model.SongImport, error) {
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultImportChunkSize
+	}
+
+	result := &model.SongImport{
+		Total:       len(rows),
+		DryRun:      opts.DryRun,
+		Errors:      make([]model.SongImportError, 0),
+		SkippedRows: make([]model.SongImportSkip, 0),
+	}
+
+	// seen maps the songs of the file to the first row they are in.
+	seen := make(map[string]int)
+	for start := 0; start < len(rows); start += chunkSize {
+		if err := ctx.Err(); err != nil {
+			return result, errors.Wrap(err, "import cancelled")
+		}
+
+		chunk := rows[start:min(start+chunkSize, len(rows))]
+		songs := s.prepareImport(ctx, chunk, opts, seen, result)
+		if len(songs) == 0 {
+			continue
+		}
+
+		save := s.saveImport
+		if opts.DryRun {
+			save = s.findExisting
+		}
+		existing, err := save(ctx, songs)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "failed to import chunk", "from", chunk[0].Row, "to", chunk[len(chunk)-1].Row, "error", err)
+			for _, imported := range songs {
+				result.Errors = append(result.Errors, model.SongImportError{Row: imported.row, Message: err.Error()})
+			}
+			result.Failed += len(songs)
+			continue
+		}
+
+		for _, imported := range existing {
+			skipImport(result, imported, "song exists already")
+		}
+		result.Imported += len(songs) - len(existing)
+	}
+
+	s.logger.InfoContext(ctx, "songs imported",
+		"total", result.Total,
+		"imported", result.Imported,
+		"failed", result.Failed,
+		"skipped", result.Skipped,
+		"dryRun", result.DryRun)
+
+	return result, nil
+}
+
+// prepareImport validates and optionally enriches a chunk. Rows that fail are
+// added to the result errors, rows repeating a song of seen are skipped.
+func (s *songService) prepareImport(ctx context.Context,
+	rows []model.SongImportRow,
+	opts model.SongImportOptions,
+	seen map[string]int,
+	result *model.SongImport) []importedSong {
+	fail := func(row int, field, message string) {
+		result.Errors = append(result.Errors, model.SongImportError{Row: row, Field: field, Message: message})
+		result.Failed++
+	}
+
+	now := time.Now()
+	songs := make([]importedSong, 0, len(rows))
+	for _, row := range rows {
+		song, field, err := toImportedSong(row, now)
+		if err != nil {
+			fail(row.Row, field, err.Error())
+			continue
+		}
+		songs = append(songs, importedSong{row: row.Row, song: song})
+	}
+
+	if opts.Enrich && len(songs) > 0 {
+		queries := make([]music_info_client.Query, 0, len(songs))
+		for _, imported := range songs {
+			queries = append(queries, music_info_client.Query{Group: imported.song.Group, Song: imported.song.Song})
+		}
+		results := s.musicInfoClient.GetSongsInfo(ctx, queries)
+
+		for i := range songs {
+			song := &songs[i].song
+			if err := mapSongDetailError(results[i].Err); err != nil {
+				// Rows that are complete without the upstream data are still imported.
+				if song.ReleaseDate.IsZero() {
+					songs[i].failed = true
+					fail(songs[i].row, "", fmt.Sprintf("failed to get song detail: %s", err))
+				}
+				continue
+			}
+			if _, err := applySongDetail(song, results[i].SongDetail, false, now); err != nil {
+				songs[i].failed = true
+				fail(songs[i].row, "", err.Error())
+				continue
+			}
+			songs[i].enriched = true
+		}
+	}
+
+	valid := songs[:0]
+	for _, imported := range songs {
+		switch {
+		case imported.failed:
+		case imported.song.ReleaseDate.IsZero():
+			fail(imported.row, model.SongFieldReleaseDate, "release date is required")
+		default:
+			key := importKey(imported.song)
+			if first, ok := seen[key]; ok {
+				skipImport(result, imported, fmt.Sprintf("duplicate of row %d", first))
+				continue
+			}
+			seen[key] = imported.row
+			valid = append(valid, imported)
+		}
+	}
+
+	return valid
+}
+
+func skipImport(result *model.SongImport, imported importedSong, reason string) {
+	result.SkippedRows = append(result.SkippedRows, model.SongImportSkip{
+		Row:    imported.row,
+		Group:  imported.song.Group,
+		Song:   imported.song.Song,
+		Reason: reason,
+	})
+	result.Skipped++
+}
+
+func importKey(song model.Song) string {
+	return song.Group + "\x00" + song.Song
+}
+
+// findExisting returns the songs of a chunk that are in the library already,
+// without saving anything.
+func (s *songService) findExisting(ctx context.Context, songs []importedSong) ([]importedSong, error) {
+	entities := make([]model.Song, 0, len(songs))
+	for _, imported := range songs {
+		entities = append(entities, imported.song)
+	}
+
+	found, err := s.songRepo.GetExisting(ctx, entities)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool, len(found))
+	for _, song := range found {
+		keys[importKey(song)] = true
+	}
+
+	existing := make([]importedSong, 0, len(found))
+	for _, imported := range songs {
+		if keys[importKey(imported.song)] {
+			existing = append(existing, imported)
+		}
+	}
+
+	return existing, nil
+}
+
+// saveImport saves a chunk and enqueues the enrich job for the songs missing
+// upstream data, in the same transaction. It returns the songs that
+// were not saved because they exist already.
+func (s *songService) saveImport(ctx context.Context, songs []importedSong) ([]importedSong, error) {
+	var existing []importedSong
+	var songsCreated, groupsCreated int
+	err := s.trManager.Do(ctx, func(ctx context.Context) error {
+		groups := make(map[string]uuid.UUID)
+		entities := make([]model.Song, 0, len(songs))
+		for _, imported := range songs {
+			song := imported.song
+
+			groupID, ok := groups[song.Group]
+			if !ok {
+				groupDB, err := s.groupRepo.GetByName(ctx, song.Group)
+				if err != nil && !errors.Is(err, model.ErrNotFound) {
+					return errors.Wrap(err, "failed to get group by name")
+				}
+
+				if groupDB == nil {
+					groupDB, err = s.groupRepo.Create(ctx, model.Group{Name: song.Group})
+					if err != nil {
+						return err
+					}
+					groupsCreated++
+
+					s.logger.InfoContext(ctx, "group created", "id", groupDB.ID, "group", groupDB.Name)
+				}
+
+				groupID = groupDB.ID
+				groups[song.Group] = groupID
+			}
+
+			song.ID = uuid.New()
+			song.GroupID = groupID
+			entities = append(entities, song)
+		}
+
+		ids, err := s.songRepo.CopyNew(ctx, entities)
+		if err != nil {
+			return err
+		}
+		songsCreated = len(ids)
+
+		inserted := make(map[uuid.UUID]bool, len(ids))
+		for _, id := range ids {
+			inserted[id] = true
+		}
+
+		existing = make([]importedSong, 0, len(songs)-len(ids))
+		payloads := make([]any, 0)
+		for i, imported := range songs {
+			switch {
+			case !inserted[entities[i].ID]:
+				existing = append(existing, imported)
+			case imported.needsEnrich():
+				payloads = append(payloads, model.SongEnrichPayload{SongID: entities[i].ID})
+			}
+		}
+		_, err = s.jobService.EnqueueMany(ctx, model.JobTypeSongEnrich, payloads)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.counter.SongsCreated(songsCreated)
+	s.counter.GroupsCreated(groupsCreated)
+
+	return existing, nil
+}
+
+// toImportedSong validates a row. Values present in the file are marked as
+// manual so that a later refresh does not overwrite them.
+func toImportedSong(row model.SongImportRow, now time.Time) (model.Song, string, error) {
+	switch {
+	case row.Group == "":
+		return model.Song{}, model.SongFieldGroup, errors.New("group is required")
+	case utf8.RuneCountInString(row.Group) > 255:
+		return model.Song{}, model.SongFieldGroup, errors.New("group must be at most 255 characters")
+	case row.Song == "":
+		return model.Song{}, model.SongFieldSong, errors.New("song is required")
+	case utf8.RuneCountInString(row.Song) > 255:
+		return model.Song{}, model.SongFieldSong, errors.New("song must be at most 255 characters")
+	case utf8.RuneCountInString(row.Link) > 2048:
+		return model.Song{}, model.SongFieldLink, errors.New("link must be at most 2048 characters")
+	}
+
+	song := model.Song{
+		Group:      row.Group,
+		Song:       row.Song,
+		Provenance: model.SongProvenance{},
+	}
+
+	if row.Text != "" {
+		song.Text = row.Text
+		song.Provenance.SetManual(model.SongFieldText, now)
+	}
+	if row.Link != "" {
+		song.Link = row.Link
+		song.Provenance.SetManual(model.SongFieldLink, now)
+	}
+	if row.ReleaseDate != "" {
+		releaseDate, err := partial_date.Parse(row.ReleaseDate)
+		if err != nil {
+			return model.Song{}, model.SongFieldReleaseDate, err
+		}
+		song.SetReleaseDate(releaseDate)
+		song.Provenance.SetManual(model.SongFieldReleaseDate, now)
+	}
+
+	return song, "", nil
+}
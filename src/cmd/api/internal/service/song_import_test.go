@@ -0,0 +1,49 @@
+package service
+
+import (
+	"song-library-api/src/cmd/api/internal/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToImportedSong_lengths(t *testing.T) {
+	tests := []struct {
+		name      string
+		row       model.SongImportRow
+		wantField string
+	}{
+		{
+			name: "multibyte names within the limit",
+			row:  model.SongImportRow{Group: strings.Repeat("ё", 255), Song: strings.Repeat("日", 255)},
+		},
+		{
+			name: "multibyte link within the limit",
+			row:  model.SongImportRow{Group: "Muse", Song: "Hysteria", Link: strings.Repeat("ü", 2048)},
+		},
+		{
+			name:      "group too long",
+			row:       model.SongImportRow{Group: strings.Repeat("ё", 256), Song: "Hysteria"},
+			wantField: model.SongFieldGroup,
+		},
+		{
+			name:      "song too long",
+			row:       model.SongImportRow{Group: "Muse", Song: strings.Repeat("a", 256)},
+			wantField: model.SongFieldSong,
+		},
+		{
+			name:      "link too long",
+			row:       model.SongImportRow{Group: "Muse", Song: "Hysteria", Link: strings.Repeat("ü", 2049)},
+			wantField: model.SongFieldLink,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, field, err := toImportedSong(tt.row, time.Now())
+			if (err != nil) != (tt.wantField != "") || field != tt.wantField {
+				t.Errorf("toImportedSong() = %q, %v, want field %q", field, err, tt.wantField)
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"github.com/pkg/errors"
+	"reflect"
+	"slices"
+	"song-library-api/src/cmd/api/internal/model"
+	musicinfo "song-library-api/src/pkg/music_info_client/model"
+	"song-library-api/src/pkg/partial_date"
+	"testing"
+	"time"
+)
+
+func releaseDate(y int, m time.Month, d int, precision partial_date.Precision) partial_date.Date {
+	return partial_date.New(time.Date(y, m, d, 0, 0, 0, 0, time.UTC), precision)
+}
+
+func TestDiffSongs(t *testing.T) {
+	before := model.Song{Text: "Verse", Link: "https://example.com/a"}
+	before.SetReleaseDate(releaseDate(2006, time.July, 16, partial_date.PrecisionDay))
+
+	tests := []struct {
+		name   string
+		change func(song *model.Song)
+		want   []model.SongFieldChange
+	}{
+		{
+			name:   "unchanged",
+			change: func(song *model.Song) {},
+			want:   []model.SongFieldChange{},
+		},
+		{
+			name:   "text",
+			change: func(song *model.Song) { song.Text = "Chorus" },
+			want:   []model.SongFieldChange{{Field: model.SongFieldText, Old: "Verse", New: "Chorus"}},
+		},
+		{
+			name:   "link cleared",
+			change: func(song *model.Song) { song.Link = "" },
+			want:   []model.SongFieldChange{{Field: model.SongFieldLink, Old: "https://example.com/a", New: ""}},
+		},
+		{
+			name: "release date precision",
+			change: func(song *model.Song) {
+				song.SetReleaseDate(releaseDate(2006, time.July, 1, partial_date.PrecisionMonth))
+			},
+			want: []model.SongFieldChange{{Field: model.SongFieldReleaseDate, Old: "16.07.2006", New: "07.2006"}},
+		},
+		{
+			name: "every field in order",
+			change: func(song *model.Song) {
+				song.Text = "Chorus"
+				song.Link = "https://example.com/b"
+				song.SetReleaseDate(releaseDate(2007, time.January, 1, partial_date.PrecisionYear))
+			},
+			want: []model.SongFieldChange{
+				{Field: model.SongFieldText, Old: "Verse", New: "Chorus"},
+				{Field: model.SongFieldLink, Old: "https://example.com/a", New: "https://example.com/b"},
+				{Field: model.SongFieldReleaseDate, Old: "16.07.2006", New: "2007"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := before
+			tt.change(&after)
+
+			if got := diffSongs(before, after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffSongs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplySongDetail(t *testing.T) {
+	manualAt := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	detail := &musicinfo.SongDetail{Text: "Upstream", Link: "https://example.com/up", ReleaseDate: "07.2006"}
+
+	tests := []struct {
+		name        string
+		manual      []string
+		force       bool
+		wantText    string
+		wantLink    string
+		wantDate    string
+		wantSkipped []string
+	}{
+		{
+			name:        "no manual fields",
+			wantText:    "Upstream",
+			wantLink:    "https://example.com/up",
+			wantDate:    "07.2006",
+			wantSkipped: []string{},
+		},
+		{
+			name:        "manual text is kept",
+			manual:      []string{model.SongFieldText},
+			wantText:    "Manual",
+			wantLink:    "https://example.com/up",
+			wantDate:    "07.2006",
+			wantSkipped: []string{model.SongFieldText},
+		},
+		{
+			name:        "every field manual",
+			manual:      []string{model.SongFieldText, model.SongFieldLink, model.SongFieldReleaseDate},
+			wantText:    "Manual",
+			wantLink:    "https://example.com/manual",
+			wantDate:    "16.07.2006",
+			wantSkipped: []string{model.SongFieldText, model.SongFieldLink, model.SongFieldReleaseDate},
+		},
+		{
+			name:        "force overwrites manual fields",
+			manual:      []string{model.SongFieldText, model.SongFieldLink, model.SongFieldReleaseDate},
+			force:       true,
+			wantText:    "Upstream",
+			wantLink:    "https://example.com/up",
+			wantDate:    "07.2006",
+			wantSkipped: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			song := model.Song{Text: "Manual", Link: "https://example.com/manual", Provenance: model.SongProvenance{}}
+			song.SetReleaseDate(releaseDate(2006, time.July, 16, partial_date.PrecisionDay))
+			for _, field := range tt.manual {
+				song.Provenance.SetManual(field, manualAt)
+			}
+
+			skipped, err := applySongDetail(&song, detail, tt.force, now)
+			if err != nil {
+				t.Fatalf("applySongDetail() error = %v", err)
+			}
+			if !reflect.DeepEqual(skipped, tt.wantSkipped) {
+				t.Errorf("skipped = %v, want %v", skipped, tt.wantSkipped)
+			}
+			if song.Text != tt.wantText || song.Link != tt.wantLink || song.PartialReleaseDate().String() != tt.wantDate {
+				t.Errorf("song = %q %q %s, want %q %q %s",
+					song.Text, song.Link, song.PartialReleaseDate(), tt.wantText, tt.wantLink, tt.wantDate)
+			}
+			for _, field := range []string{model.SongFieldText, model.SongFieldLink, model.SongFieldReleaseDate} {
+				provenance := song.Provenance[field]
+				if slices.Contains(tt.wantSkipped, field) {
+					if !provenance.UpdatedAt.Equal(manualAt) || !song.Provenance.IsManual(field) {
+						t.Errorf("provenance of %s = %+v, want it kept manual", field, provenance)
+					}
+				} else if provenance.Source != model.FieldSourceUpstream || !provenance.UpdatedAt.Equal(now) {
+					t.Errorf("provenance of %s = %+v, want upstream at %s", field, provenance, now)
+				}
+			}
+		})
+	}
+}
+
+func TestApplySongDetail_invalidReleaseDate(t *testing.T) {
+	song := model.Song{Text: "Manual", Provenance: model.SongProvenance{}}
+
+	_, err := applySongDetail(&song, &musicinfo.SongDetail{Text: "Upstream", ReleaseDate: "someday"}, false, time.Now())
+	if err == nil {
+		t.Fatal("applySongDetail() error = nil, want an error")
+	}
+	if song.Text != "Manual" {
+		t.Errorf("Text = %q, want the song left as is", song.Text)
+	}
+}
+
+func TestPatchSong(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	upstreamAt := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	date := releaseDate(2007, time.March, 1, partial_date.PrecisionMonth)
+
+	tests := []struct {
+		name       string
+		patch      model.SongPatch
+		want       func(song *model.Song)
+		wantManual []string
+		wantReset  []string
+		wantLookup bool
+		wantErr    bool
+	}{
+		{
+			name:  "empty patch",
+			patch: model.SongPatch{},
+			want:  func(song *model.Song) {},
+		},
+		{
+			name:       "text becomes manual",
+			patch:      model.SongPatch{Text: model.Some("Manual")},
+			want:       func(song *model.Song) { song.Text = "Manual" },
+			wantManual: []string{model.SongFieldText},
+		},
+		{
+			name:       "empty text is a value",
+			patch:      model.SongPatch{Text: model.Some("")},
+			want:       func(song *model.Song) { song.Text = "" },
+			wantManual: []string{model.SongFieldText},
+		},
+		{
+			name:       "release date becomes manual",
+			patch:      model.SongPatch{ReleaseDate: model.Some(date)},
+			want:       func(song *model.Song) { song.SetReleaseDate(date) },
+			wantManual: []string{model.SongFieldReleaseDate},
+		},
+		{
+			name:       "null resets from upstream",
+			patch:      model.SongPatch{Link: model.Null[string]()},
+			want:       func(song *model.Song) {},
+			wantReset:  []string{model.SongFieldLink},
+			wantLookup: true,
+		},
+		{
+			name:       "null release date resets from upstream",
+			patch:      model.SongPatch{ReleaseDate: model.Null[partial_date.Date]()},
+			want:       func(song *model.Song) {},
+			wantReset:  []string{model.SongFieldReleaseDate},
+			wantLookup: true,
+		},
+		{
+			name:       "rename looks up",
+			patch:      model.SongPatch{Song: model.Some("Renamed")},
+			want:       func(song *model.Song) { song.Song = "Renamed" },
+			wantLookup: true,
+		},
+		{
+			name:  "same name does not look up",
+			patch: model.SongPatch{Group: model.Some("Muse"), Song: model.Some("Hysteria")},
+			want:  func(song *model.Song) {},
+		},
+		{
+			name:       "refresh looks up",
+			patch:      model.SongPatch{Refresh: true},
+			want:       func(song *model.Song) {},
+			wantLookup: true,
+		},
+		{
+			name:    "null group",
+			patch:   model.SongPatch{Group: model.Null[string]()},
+			wantErr: true,
+		},
+		{
+			name:    "empty song",
+			patch:   model.SongPatch{Song: model.Some("")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			songDB := model.Song{Group: "Muse", Song: "Hysteria", Text: "Upstream", Link: "https://example.com/up"}
+			songDB.SetReleaseDate(releaseDate(2003, time.December, 1, partial_date.PrecisionDay))
+			songDB.Provenance = model.SongProvenance{}
+			for _, field := range []string{model.SongFieldText, model.SongFieldLink, model.SongFieldReleaseDate} {
+				songDB.Provenance.SetUpstream(field, model.ProviderMusicInfo, upstreamAt)
+			}
+
+			got, lookup, err := patchSong(songDB, tt.patch, now)
+			if tt.wantErr {
+				if !errors.Is(err, model.ErrBadRequest) {
+					t.Fatalf("patchSong() error = %v, want ErrBadRequest", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("patchSong() error = %v", err)
+			}
+			if lookup != tt.wantLookup {
+				t.Errorf("lookup = %t, want %t", lookup, tt.wantLookup)
+			}
+
+			want := songDB
+			want.Provenance = got.Provenance
+			tt.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("patchSong() = %+v, want %+v", got, want)
+			}
+
+			for field, provenance := range got.Provenance {
+				switch {
+				case slices.Contains(tt.wantManual, field):
+					if !got.Provenance.IsManual(field) || !provenance.UpdatedAt.Equal(now) {
+						t.Errorf("provenance of %s = %+v, want manual at %s", field, provenance, now)
+					}
+				case !provenance.UpdatedAt.Equal(upstreamAt):
+					t.Errorf("provenance of %s = %+v, want it unchanged", field, provenance)
+				}
+			}
+			for _, field := range tt.wantReset {
+				if _, ok := got.Provenance[field]; ok {
+					t.Errorf("provenance of %s kept, want it dropped", field)
+				}
+			}
+			if len(songDB.Provenance) != 3 {
+				t.Errorf("provenance of the stored song changed to %v", songDB.Provenance)
+			}
+		})
+	}
+}
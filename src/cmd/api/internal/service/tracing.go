@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/cmd/api/internal/tracing"
+)
+
+var _ SongService = (*songServiceTracing)(nil)
+
+type songServiceTracing struct {
+	base SongService
+}
+
+func NewSongServiceWithTracing(base SongService) *songServiceTracing {
+	return &songServiceTracing{
+		base: base,
+	}
+}
+
+func (s *songServiceTracing) GetSongs(ctx context.Context, filters *model.SongFilter, page, pageSize uint) (list *model.PaginatedList[model.Song], err error) {
+	ctx, span := tracing.Start(ctx, "SongService.GetSongs")
+	defer tracing.End(span, &err)
+	return s.base.GetSongs(ctx, filters, page, pageSize)
+}
+
+func (s *songServiceTracing) Export(ctx context.Context, filters *model.SongFilter, fn func(song model.Song) error) (err error) {
+	ctx, span := tracing.Start(ctx, "SongService.Export")
+	defer tracing.End(span, &err)
+	return s.base.Export(ctx, filters, fn)
+}
+
+func (s *songServiceTracing) GetSongText(ctx context.Context, id uuid.UUID, page, pageSize uint) (list *model.PaginatedList[string], err error) {
+	ctx, span := tracing.Start(ctx, "SongService.GetSongText")
+	defer tracing.End(span, &err)
+	return s.base.GetSongText(ctx, id, page, pageSize)
+}
+
+func (s *songServiceTracing) GetByID(ctx context.Context, id uuid.UUID) (song *model.Song, err error) {
+	ctx, span := tracing.Start(ctx, "SongService.GetByID")
+	defer tracing.End(span, &err)
+	return s.base.GetByID(ctx, id)
+}
+
+func (s *songServiceTracing) Add(ctx context.Context, song, group string) (created *model.Song, err error) {
+	ctx, span := tracing.Start(ctx, "SongService.Add")
+	defer tracing.End(span, &err)
+	return s.base.Add(ctx, song, group)
+}
+
+func (s *songServiceTracing) Edit(ctx context.Context, patch model.SongPatch) (song *model.Song, err error) {
+	ctx, span := tracing.Start(ctx, "SongService.Edit")
+	defer tracing.End(span, &err)
+	return s.base.Edit(ctx, patch)
+}
+
+func (s *songServiceTracing) Refresh(ctx context.Context, id uuid.UUID, opts model.SongRefreshOptions) (refresh *model.SongRefresh, err error) {
+	ctx, span := tracing.Start(ctx, "SongService.Refresh")
+	defer tracing.End(span, &err)
+	return s.base.Refresh(ctx, id, opts)
+}
+
+func (s *songServiceTracing) RefreshGroup(ctx context.Context, group string, opts model.SongRefreshOptions) (refreshes []model.SongRefresh, err error) {
+	ctx, span := tracing.Start(ctx, "SongService.RefreshGroup")
+	defer tracing.End(span, &err)
+	return s.base.RefreshGroup(ctx, group, opts)
+}
+
+func (s *songServiceTracing) Import(ctx context.Context, rows []model.SongImportRow, opts model.SongImportOptions) (result *model.SongImport, err error) {
+	ctx, span := tracing.Start(ctx, "SongService.Import")
+	defer tracing.End(span, &err)
+	return s.base.Import(ctx, rows, opts)
+}
+
+func (s *songServiceTracing) Delete(ctx context.Context, id uuid.UUID) (song *model.Song, err error) {
+	ctx, span := tracing.Start(ctx, "SongService.Delete")
+	defer tracing.End(span, &err)
+	return s.base.Delete(ctx, id)
+}
+
+var _ GroupService = (*groupServiceTracing)(nil)
+
+type groupServiceTracing struct {
+	base GroupService
+}
+
+func NewGroupServiceWithTracing(base GroupService) *groupServiceTracing {
+	return &groupServiceTracing{
+		base: base,
+	}
+}
+
+func (s *groupServiceTracing) GetByName(ctx context.Context, group string) (groupDB *model.Group, err error) {
+	ctx, span := tracing.Start(ctx, "GroupService.GetByName")
+	defer tracing.End(span, &err)
+	return s.base.GetByName(ctx, group)
+}
+
+func (s *groupServiceTracing) Merge(ctx context.Context, target string, sources []string) (merge *model.GroupMerge, err error) {
+	ctx, span := tracing.Start(ctx, "GroupService.Merge")
+	defer tracing.End(span, &err)
+	return s.base.Merge(ctx, target, sources)
+}
+
+var _ JobService = (*jobServiceTracing)(nil)
+
+type jobServiceTracing struct {
+	base JobService
+}
+
+func NewJobServiceWithTracing(base JobService) *jobServiceTracing {
+	return &jobServiceTracing{
+		base: base,
+	}
+}
+
+func (s *jobServiceTracing) Enqueue(ctx context.Context, jobType string, payload any) (job *model.Job, err error) {
+	ctx, span := tracing.Start(ctx, "JobService.Enqueue")
+	defer tracing.End(span, &err)
+	return s.base.Enqueue(ctx, jobType, payload)
+}
+
+func (s *jobServiceTracing) EnqueueMany(ctx context.Context, jobType string, payloads []any) (count int64, err error) {
+	ctx, span := tracing.Start(ctx, "JobService.EnqueueMany")
+	defer tracing.End(span, &err)
+	return s.base.EnqueueMany(ctx, jobType, payloads)
+}
+
+var _ APIKeyService = (*apiKeyServiceTracing)(nil)
+
+type apiKeyServiceTracing struct {
+	base APIKeyService
+}
+
+func NewAPIKeyServiceWithTracing(base APIKeyService) *apiKeyServiceTracing {
+	return &apiKeyServiceTracing{
+		base: base,
+	}
+}
+
+func (s *apiKeyServiceTracing) Create(ctx context.Context, name string) (apiKey *model.CreatedAPIKey, err error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.Create")
+	defer tracing.End(span, &err)
+	return s.base.Create(ctx, name)
+}
+
+func (s *apiKeyServiceTracing) Revoke(ctx context.Context, id uuid.UUID) (apiKey *model.APIKey, err error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.Revoke")
+	defer tracing.End(span, &err)
+	return s.base.Revoke(ctx, id)
+}
+
+func (s *apiKeyServiceTracing) Authenticate(ctx context.Context, key string) (apiKey *model.APIKey, err error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.Authenticate")
+	defer tracing.End(span, &err)
+	return s.base.Authenticate(ctx, key)
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+)
+
+var (
+	_ pgx.QueryTracer    = (*QueryTracer)(nil)
+	_ pgx.CopyFromTracer = (*QueryTracer)(nil)
+)
+
+// QueryTracer starts a client span for every query sent through pgx.
+type QueryTracer struct {
+	// Queries are built with literal values, so their text contains song
+	// lyrics and key hashes and is only recorded when asked for.
+	includeStatement bool
+}
+
+func NewQueryTracer(includeStatement bool) *QueryTracer {
+	return &QueryTracer{
+		includeStatement: includeStatement,
+	}
+}
+
+func (t *QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	operation := queryOperation(data.SQL)
+
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemPostgreSQL,
+		semconv.DBOperationName(operation),
+	}
+	if t.includeStatement {
+		attrs = append(attrs, semconv.DBQueryText(data.SQL))
+	}
+
+	ctx, _ = tracer().Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+	return ctx
+}
+
+func (t *QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	endQuerySpan(trace.SpanFromContext(ctx), data.CommandTag.RowsAffected(), data.Err)
+}
+
+func (t *QueryTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	table := data.TableName.Sanitize()
+
+	ctx, _ = tracer().Start(ctx, "COPY "+table,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName("COPY"),
+			semconv.DBCollectionName(table)))
+	return ctx
+}
+
+func (t *QueryTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	endQuerySpan(trace.SpanFromContext(ctx), data.CommandTag.RowsAffected(), data.Err)
+}
+
+func endQuerySpan(span trace.Span, rows int64, err error) {
+	span.SetAttributes(attribute.Int64("db.rows_affected", rows))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// queryOperation returns the leading keyword of the statement, such as SELECT.
+func queryOperation(sql string) string {
+	operation, _, _ := strings.Cut(strings.TrimSpace(sql), " ")
+	if operation == "" {
+		return "QUERY"
+	}
+	return strings.ToUpper(operation)
+}
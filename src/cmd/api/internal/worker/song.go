@@ -0,0 +1,26 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/cmd/api/internal/service"
+)
+
+func NewSongEnrichHandler(songService service.SongService) Handler {
+	return func(ctx context.Context, job model.Job) error {
+		var payload model.SongEnrichPayload
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return errors.Wrap(err, "failed to unmarshal job payload")
+		}
+
+		// A song deleted after the job was enqueued, or one the music info
+		// service does not know, has nothing left to enrich.
+		_, err := songService.Refresh(ctx, payload.SongID, model.SongRefreshOptions{})
+		if errors.Is(err, model.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+}
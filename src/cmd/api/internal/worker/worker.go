@@ -0,0 +1,167 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"log/slog"
+	"song-library-api/src/cmd/api/internal/logging"
+	"song-library-api/src/cmd/api/internal/model"
+	"song-library-api/src/cmd/api/internal/repository"
+	"song-library-api/src/cmd/api/internal/tracing"
+	"sync"
+	"time"
+)
+
+// Handler processes a single job. A returned error schedules a retry until
+// the job runs out of attempts, after which it is dead-lettered.
+type Handler func(ctx context.Context, job model.Job) error
+
+type Worker struct {
+	jobRepo      repository.JobRepository
+	handlers     map[string]Handler
+	concurrency  int
+	pollInterval time.Duration
+	lockTimeout  time.Duration
+	retryBackoff time.Duration
+	logger       *slog.Logger
+}
+
+func NewWorker(
+	jobRepo repository.JobRepository,
+	concurrency int,
+	pollInterval time.Duration,
+	lockTimeout time.Duration,
+	retryBackoff time.Duration,
+	logger *slog.Logger) *Worker {
+	return &Worker{
+		jobRepo:      jobRepo,
+		handlers:     make(map[string]Handler),
+		concurrency:  max(concurrency, 1),
+		pollInterval: pollInterval,
+		lockTimeout:  lockTimeout,
+		retryBackoff: retryBackoff,
+		logger:       logger,
+	}
+}
+
+func (w *Worker) Register(jobType string, handler Handler) {
+	w.handlers[jobType] = handler
+}
+
+// Run polls the queue until ctx is cancelled and then waits for the jobs
+// that are already in progress. Running jobs are not interrupted by shutdown,
+// only by their deadline, see handlerTimeout.
+func (w *Worker) Run(ctx context.Context) error {
+	w.logger.Info("worker started", "concurrency", w.concurrency)
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+
+	w.logger.Info("worker stopped")
+
+	return nil
+}
+
+func (w *Worker) loop(ctx context.Context) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue before going back to sleep.
+		for ctx.Err() == nil && w.processNext(context.WithoutCancel(ctx)) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Worker) processNext(ctx context.Context) bool {
+	// Most polls find nothing, only the jobs themselves are traced.
+	job, err := w.jobRepo.Dequeue(tracing.Suppress(ctx), w.lockTimeout)
+	if err != nil {
+		if !errors.Is(err, model.ErrNotFound) {
+			w.logger.Error("failed to dequeue job", "error", err)
+		}
+		return false
+	}
+
+	ctx = logging.With(ctx, "jobId", job.ID, "jobType", job.Type)
+	ctx, span := tracing.Start(ctx, "Job "+job.Type,
+		attribute.String("job.id", job.ID.String()),
+		attribute.Int("job.attempts", job.Attempts))
+	defer span.End()
+
+	if err = w.handle(ctx, *job); err == nil {
+		if err = w.jobRepo.Complete(ctx, *job); err != nil {
+			w.logStateError(ctx, "failed to complete job", job, err)
+		}
+		return true
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+
+	if job.Attempts >= job.MaxAttempts {
+		w.logger.ErrorContext(ctx, "job dead-lettered", "id", job.ID, "type", job.Type, "attempts", job.Attempts, "error", err)
+		if err = w.jobRepo.Bury(ctx, *job, err.Error()); err != nil {
+			w.logStateError(ctx, "failed to bury job", job, err)
+		}
+		return true
+	}
+
+	delay := w.retryBackoff * time.Duration(1<<min(job.Attempts-1, 10))
+	w.logger.WarnContext(ctx, "job failed", "id", job.ID, "type", job.Type, "attempts", job.Attempts, "retryIn", delay, "error", err)
+	if err = w.jobRepo.Retry(ctx, *job, err.Error(), delay); err != nil {
+		w.logStateError(ctx, "failed to retry job", job, err)
+	}
+
+	return true
+}
+
+// logStateError reports a failed state change. Losing the lock is expected
+// when a job outlives its timeout, the worker holding it now decides its state.
+func (w *Worker) logStateError(ctx context.Context, msg string, job *model.Job, err error) {
+	if errors.Is(err, model.ErrJobLockLost) {
+		w.logger.WarnContext(ctx, msg, "id", job.ID, "error", err)
+		return
+	}
+	w.logger.ErrorContext(ctx, msg, "id", job.ID, "error", err)
+}
+
+// handlerTimeout leaves a tenth of the lock timeout to record the result, so
+// that a slow job is cancelled before another worker can claim it again.
+func (w *Worker) handlerTimeout() time.Duration {
+	return w.lockTimeout - w.lockTimeout/10
+}
+
+func (w *Worker) handle(ctx context.Context, job model.Job) (err error) {
+	handler, ok := w.handlers[job.Type]
+	if !ok {
+		return errors.Errorf("no handler registered for job type %q", job.Type)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.handlerTimeout())
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job handler panicked: %v", r)
+		}
+	}()
+
+	return handler(ctx, job)
+}
@@ -0,0 +1,176 @@
+package worker
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"io"
+	"log/slog"
+	"song-library-api/src/cmd/api/internal/model"
+	"testing"
+	"time"
+)
+
+// fakeJobRepository hands out a single job and records what happened to it.
+type fakeJobRepository struct {
+	job       *model.Job
+	state     string
+	lastError string
+	delay     time.Duration
+}
+
+func (r *fakeJobRepository) Enqueue(context.Context, model.Job) (*model.Job, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *fakeJobRepository) EnqueueMany(context.Context, []model.Job) (int64, error) {
+	return 0, errors.New("not implemented")
+}
+
+func (r *fakeJobRepository) Dequeue(context.Context, time.Duration) (*model.Job, error) {
+	if r.job == nil {
+		return nil, errors.Wrap(model.ErrNotFound, "no job available")
+	}
+	job := r.job
+	r.job = nil
+	return job, nil
+}
+
+func (r *fakeJobRepository) Complete(context.Context, model.Job) error {
+	r.state = "complete"
+	return nil
+}
+
+func (r *fakeJobRepository) Retry(_ context.Context, _ model.Job, lastError string, delay time.Duration) error {
+	r.state, r.lastError, r.delay = "retry", lastError, delay
+	return nil
+}
+
+func (r *fakeJobRepository) Bury(_ context.Context, _ model.Job, lastError string) error {
+	r.state, r.lastError = "bury", lastError
+	return nil
+}
+
+func TestWorker_processNext(t *testing.T) {
+	failed := func(context.Context, model.Job) error { return errors.New("upstream down") }
+
+	tests := []struct {
+		name        string
+		jobType     string
+		attempts    int
+		maxAttempts int
+		handler     Handler
+		wantState   string
+		wantError   string
+		wantDelay   time.Duration
+	}{
+		{
+			name:        "success",
+			attempts:    1,
+			maxAttempts: 3,
+			handler:     func(context.Context, model.Job) error { return nil },
+			wantState:   "complete",
+		},
+		{
+			name:        "first failure",
+			attempts:    1,
+			maxAttempts: 3,
+			handler:     failed,
+			wantState:   "retry",
+			wantError:   "upstream down",
+			wantDelay:   time.Second,
+		},
+		{
+			name:        "backoff doubles",
+			attempts:    3,
+			maxAttempts: 5,
+			handler:     failed,
+			wantState:   "retry",
+			wantError:   "upstream down",
+			wantDelay:   4 * time.Second,
+		},
+		{
+			name:        "backoff is capped",
+			attempts:    20,
+			maxAttempts: 30,
+			handler:     failed,
+			wantState:   "retry",
+			wantError:   "upstream down",
+			wantDelay:   1024 * time.Second,
+		},
+		{
+			name:        "out of attempts",
+			attempts:    3,
+			maxAttempts: 3,
+			handler:     failed,
+			wantState:   "bury",
+			wantError:   "upstream down",
+		},
+		{
+			name:        "panic",
+			attempts:    1,
+			maxAttempts: 3,
+			handler:     func(context.Context, model.Job) error { panic("boom") },
+			wantState:   "retry",
+			wantError:   "job handler panicked: boom",
+			wantDelay:   time.Second,
+		},
+		{
+			name:        "unknown type",
+			jobType:     "unknown",
+			attempts:    1,
+			maxAttempts: 1,
+			handler:     failed,
+			wantState:   "bury",
+			wantError:   `no handler registered for job type "unknown"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobType := tt.jobType
+			if jobType == "" {
+				jobType = "test"
+			}
+			repo := &fakeJobRepository{job: &model.Job{
+				ID:          uuid.New(),
+				Type:        jobType,
+				Attempts:    tt.attempts,
+				MaxAttempts: tt.maxAttempts,
+			}}
+			w := NewWorker(repo, 1, time.Second, time.Minute, time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			w.Register("test", tt.handler)
+
+			if !w.processNext(context.Background()) {
+				t.Fatal("processNext() = false, want true")
+			}
+			if repo.state != tt.wantState || repo.lastError != tt.wantError || repo.delay != tt.wantDelay {
+				t.Errorf("job %s (%q, %s), want %s (%q, %s)",
+					repo.state, repo.lastError, repo.delay, tt.wantState, tt.wantError, tt.wantDelay)
+			}
+		})
+	}
+}
+
+func TestWorker_processNext_emptyQueue(t *testing.T) {
+	w := NewWorker(&fakeJobRepository{}, 1, time.Second, time.Minute, time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if w.processNext(context.Background()) {
+		t.Error("processNext() = true, want false")
+	}
+}
+
+func TestWorker_handlerTimeout(t *testing.T) {
+	w := NewWorker(&fakeJobRepository{}, 1, time.Second, time.Minute, time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	w.Register("test", func(ctx context.Context, _ model.Job) error {
+		deadline, ok := ctx.Deadline()
+		if !ok || time.Until(deadline) > 54*time.Second {
+			t.Errorf("deadline in %s, want at most 54s", time.Until(deadline))
+		}
+		return nil
+	})
+
+	if err := w.handle(context.Background(), model.Job{Type: "test"}); err != nil {
+		t.Errorf("handle() error = %v", err)
+	}
+}
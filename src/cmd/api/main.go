@@ -1,22 +1,32 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
 	_ "song-library-api/src/cmd/api/docs"
 	"song-library-api/src/cmd/api/internal/app"
+	"syscall"
 )
 
+// The docs of every API version are generated separately, v2 from the
+// general info in its package.
+//go:generate swag init -g main.go -d ./ --exclude ./internal/server/http/v2 --parseInternal -o ./docs
+//go:generate swag init -g internal/server/http/v2/doc.go -d ./ --exclude ./internal/server/http/v1 --parseInternal --instanceName v2 -o ./docs
+
 // @title Song Library API
 // @version 1.0
 // @description Song Library API
 // @BasePath /
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
 func main() {
-	application, err := app.New()
-	if err != nil {
-		log.Fatal(err)
-	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	if err := application.Run(); err != nil {
+	if err := app.NewCLI().RunContext(ctx, os.Args); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"embed"
+	"github.com/pkg/errors"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// FS holds the SQL migrations, so the binary does not depend on the working directory.
+//
+//go:embed postgresql/*.sql
+var FS embed.FS
+
+// PostgreSQL is the directory of the PostgreSQL migrations in FS.
+const PostgreSQL = "postgresql"
+
+// Latest returns the highest migration version in dir.
+func Latest(dir string) (uint, error) {
+	entries, err := fs.ReadDir(FS, dir)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read migrations")
+	}
+
+	var latest uint64
+	for _, entry := range entries {
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		if !ok {
+			continue
+		}
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		latest = max(latest, version)
+	}
+
+	return uint(latest), nil
+}
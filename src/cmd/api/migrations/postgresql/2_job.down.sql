@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS job_status_scheduled_at_idx;
+DROP TABLE IF EXISTS "job";
\ No newline at end of file
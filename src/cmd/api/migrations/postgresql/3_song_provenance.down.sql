@@ -0,0 +1 @@
+ALTER TABLE "song" DROP COLUMN IF EXISTS provenance;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "song" ADD COLUMN IF NOT EXISTS provenance JSONB NOT NULL DEFAULT '{}';
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "music_info_cache";
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS "music_info_cache" (
+    key TEXT PRIMARY KEY,
+    song_detail JSONB,
+    not_found BOOLEAN NOT NULL DEFAULT FALSE,
+    etag TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "song" DROP COLUMN IF EXISTS release_date_precision;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "song" ADD COLUMN IF NOT EXISTS release_date_precision VARCHAR(5) NOT NULL DEFAULT 'day';
\ No newline at end of file
@@ -0,0 +1,9 @@
+-- Counters are cheap to lose, so the table skips the write-ahead log.
+CREATE UNLOGGED TABLE IF NOT EXISTS "rate_limit" (
+    key TEXT PRIMARY KEY,
+    window_start TIMESTAMPTZ NOT NULL,
+    hits INTEGER NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS rate_limit_expires_at_idx ON "rate_limit" (expires_at);
\ No newline at end of file
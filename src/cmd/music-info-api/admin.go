@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type AdminController struct {
+	store  *FixtureStore
+	faults *FaultInjector
+}
+
+func NewAdminController(store *FixtureStore, faults *FaultInjector) *AdminController {
+	return &AdminController{
+		store:  store,
+		faults: faults,
+	}
+}
+
+func (c *AdminController) ListFixtures(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, c.store.List())
+}
+
+// AddFixtures accepts a single fixture or a list of them.
+func (c *AdminController) AddFixtures(ctx echo.Context) error {
+	var raw json.RawMessage
+	if err := ctx.Bind(&raw); err != nil {
+		return echo.ErrBadRequest
+	}
+
+	var fixtures []Fixture
+	if err := json.Unmarshal(raw, &fixtures); err != nil {
+		var fixture Fixture
+		if err = json.Unmarshal(raw, &fixture); err != nil {
+			return echo.ErrBadRequest
+		}
+		fixtures = []Fixture{fixture}
+	}
+
+	for _, fixture := range fixtures {
+		if fixture.Group == "" || fixture.Song == "" {
+			return ctx.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Both 'group' and 'song' fields are required",
+			})
+		}
+	}
+	for _, fixture := range fixtures {
+		c.store.Put(fixture)
+	}
+
+	return ctx.JSON(http.StatusCreated, fixtures)
+}
+
+func (c *AdminController) DeleteFixture(ctx echo.Context) error {
+	if !c.store.Delete(ctx.QueryParam("group"), ctx.QueryParam("song")) {
+		return echo.ErrNotFound
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (c *AdminController) ResetFixtures(ctx echo.Context) error {
+	c.store.Reset(nil)
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (c *AdminController) GetFaults(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, c.faults.Get())
+}
+
+func (c *AdminController) SetFaults(ctx echo.Context) error {
+	var faults Faults
+	if err := ctx.Bind(&faults); err != nil {
+		return echo.ErrBadRequest
+	}
+	c.faults.Set(faults)
+	return ctx.JSON(http.StatusOK, faults)
+}
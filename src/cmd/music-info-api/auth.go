@@ -0,0 +1,57 @@
+package main
+
+import (
+	"crypto/subtle"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"song-library-api/src/pkg/music_info_client"
+	"strings"
+	"time"
+)
+
+const (
+	AuthNone   = "none"
+	AuthAPIKey = "apikey"
+	AuthBearer = "bearer"
+	AuthHMAC   = "hmac"
+)
+
+const maxSignatureSkew = 5 * time.Minute
+
+// NewAuthMiddleware enforces the same credentials the music info client can send.
+func NewAuthMiddleware(cfg *Config) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if strings.HasPrefix(c.Request().URL.Path, "/admin") {
+				return next(c)
+			}
+
+			var authorized bool
+			switch cfg.AuthMode {
+			case AuthAPIKey:
+				authorized = secureEqual(c.Request().Header.Get(cfg.AuthAPIKeyHeader), cfg.AuthAPIKey)
+			case AuthBearer:
+				authorized = secureEqual(c.Request().Header.Get("Authorization"), "Bearer "+cfg.AuthBearerToken)
+			case AuthHMAC:
+				secretFor := func(keyID string) (string, bool) {
+					return cfg.AuthHMACSecret, keyID == cfg.AuthHMACKeyID
+				}
+				authorized = music_info_client.VerifyRequest(c.Request(), secretFor, maxSignatureSkew, time.Now()) == nil
+			default:
+				authorized = true
+			}
+
+			if !authorized {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "Unauthorized",
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func secureEqual(actual, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(actual), []byte(expected)) == 1
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"github.com/kelseyhightower/envconfig"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const (
+	ModeFake   = "fake"
+	ModeRecord = "record"
+	ModeReplay = "replay"
+)
+
+type Config struct {
+	Address      string `envconfig:"ADDRESS" default:"0.0.0.0:8081"`
+	Mode         string `envconfig:"MODE" default:"fake"`
+	FixturesPath string `envconfig:"FIXTURES_PATH"`
+
+	UpstreamURL   string `envconfig:"UPSTREAM_URL"`
+	RecordingsDir string `envconfig:"RECORDINGS_DIR" default:"recordings"`
+	// RecordErrors also records non-2xx upstream responses, which replace
+	// the recordings of earlier successful requests.
+	RecordErrors bool `envconfig:"RECORD_ERRORS"`
+
+	Latency        time.Duration `envconfig:"LATENCY"`
+	LatencyJitter  time.Duration `envconfig:"LATENCY_JITTER"`
+	ErrorRate      float64       `envconfig:"ERROR_RATE"`
+	RateLimit      float64       `envconfig:"RATE_LIMIT"`
+	RateLimitBurst int           `envconfig:"RATE_LIMIT_BURST" default:"1"`
+
+	AuthMode         string `envconfig:"AUTH_MODE" default:"none"`
+	AuthAPIKeyHeader string `envconfig:"AUTH_API_KEY_HEADER" default:"X-API-Key"`
+	AuthAPIKey       string `envconfig:"AUTH_API_KEY"`
+	AuthBearerToken  string `envconfig:"AUTH_BEARER_TOKEN"`
+	AuthHMACKeyID    string `envconfig:"AUTH_HMAC_KEY_ID"`
+	AuthHMACSecret   string `envconfig:"AUTH_HMAC_SECRET"`
+}
+
+func FromEnv() (*Config, error) {
+	cfg := &Config{}
+	if err := envconfig.Process("MUSIC_INFO_API", cfg); err != nil {
+		return nil, errors.Wrap(err, "init config")
+	}
+
+	switch cfg.Mode {
+	case ModeFake, ModeReplay:
+	case ModeRecord:
+		if cfg.UpstreamURL == "" {
+			return nil, errors.New("init config: upstream url is required in record mode")
+		}
+	default:
+		return nil, errors.Errorf("init config: unknown mode %q", cfg.Mode)
+	}
+
+	switch cfg.AuthMode {
+	case AuthNone, AuthAPIKey, AuthBearer, AuthHMAC:
+	default:
+		return nil, errors.Errorf("init config: unknown auth mode %q", cfg.AuthMode)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Faults describes injected upstream misbehaviour.
+type Faults struct {
+	Latency        Duration `json:"latency"`
+	LatencyJitter  Duration `json:"latencyJitter"`
+	ErrorRate      float64  `json:"errorRate"`
+	RateLimit      float64  `json:"rateLimit"`
+	RateLimitBurst int      `json:"rateLimitBurst"`
+}
+
+// Duration is written to JSON as a duration string such as "250ms". It is
+// read from a string or from a number of nanoseconds.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch value := value.(type) {
+	case string:
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.Wrapf(err, "invalid duration %q", value)
+		}
+		*d = Duration(duration)
+	case float64:
+		*d = Duration(value)
+	default:
+		return errors.Errorf("invalid duration %s", data)
+	}
+	return nil
+}
+
+type FaultInjector struct {
+	mu      sync.RWMutex
+	faults  Faults
+	limiter *rate.Limiter
+}
+
+func NewFaultInjector(faults Faults) *FaultInjector {
+	f := &FaultInjector{}
+	f.Set(faults)
+	return f
+}
+
+func (f *FaultInjector) Get() Faults {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.faults
+}
+
+func (f *FaultInjector) Set(faults Faults) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.faults = faults
+	f.limiter = nil
+	if faults.RateLimit > 0 {
+		f.limiter = rate.NewLimiter(rate.Limit(faults.RateLimit), max(faults.RateLimitBurst, 1))
+	}
+}
+
+func (f *FaultInjector) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		f.mu.RLock()
+		faults, limiter := f.faults, f.limiter
+		f.mu.RUnlock()
+
+		if limiter != nil {
+			reservation := limiter.Reserve()
+			if delay := reservation.Delay(); delay > 0 {
+				reservation.Cancel()
+				retryAfter := int(math.Ceil(delay.Seconds()))
+				c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				return c.JSON(http.StatusTooManyRequests, map[string]string{
+					"error": "Rate limit exceeded",
+				})
+			}
+		}
+
+		latency := time.Duration(faults.Latency)
+		if faults.LatencyJitter > 0 {
+			latency += rand.N(time.Duration(faults.LatencyJitter))
+		}
+		if latency > 0 {
+			select {
+			case <-time.After(latency):
+			case <-c.Request().Context().Done():
+				return c.Request().Context().Err()
+			}
+		}
+
+		if faults.ErrorRate > 0 && rand.Float64() < faults.ErrorRate {
+			return echo.ErrInternalServerError
+		}
+
+		return next(c)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"os"
+	"song-library-api/src/pkg/music_info_client/model"
+	"sort"
+	"sync"
+)
+
+type Fixture struct {
+	Group string `json:"group"`
+	Song  string `json:"song"`
+	model.SongDetail
+}
+
+var defaultFixtures = []Fixture{
+	{
+		Group: "Muse",
+		Song:  "Supermassive Black Hole",
+		SongDetail: model.SongDetail{
+			ReleaseDate: "16.07.2006",
+			Text:        "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight",
+			Link:        "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+		},
+	},
+}
+
+// LoadFixtures reads a JSON or YAML list of fixtures.
+func LoadFixtures(path string) ([]Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read fixtures")
+	}
+
+	// YAML is a superset of JSON, so one decoder covers both formats.
+	var fixtures []Fixture
+	if err = yaml.Unmarshal(data, &fixtures); err != nil {
+		return nil, errors.Wrap(err, "failed to decode fixtures")
+	}
+
+	return fixtures, nil
+}
+
+type FixtureStore struct {
+	mu       sync.RWMutex
+	fixtures map[fixtureKey]Fixture
+}
+
+type fixtureKey struct {
+	group string
+	song  string
+}
+
+func NewFixtureStore(fixtures []Fixture) *FixtureStore {
+	s := &FixtureStore{fixtures: make(map[fixtureKey]Fixture, len(fixtures))}
+	for _, fixture := range fixtures {
+		s.Put(fixture)
+	}
+	return s
+}
+
+func (s *FixtureStore) Get(group, song string) (Fixture, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fixture, ok := s.fixtures[fixtureKey{group: group, song: song}]
+	return fixture, ok
+}
+
+func (s *FixtureStore) List() []Fixture {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fixtures := make([]Fixture, 0, len(s.fixtures))
+	for _, fixture := range s.fixtures {
+		fixtures = append(fixtures, fixture)
+	}
+	sort.Slice(fixtures, func(i, j int) bool {
+		if fixtures[i].Group != fixtures[j].Group {
+			return fixtures[i].Group < fixtures[j].Group
+		}
+		return fixtures[i].Song < fixtures[j].Song
+	})
+	return fixtures
+}
+
+func (s *FixtureStore) Put(fixture Fixture) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fixtures[fixtureKey{group: fixture.Group, song: fixture.Song}] = fixture
+}
+
+func (s *FixtureStore) Delete(group, song string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := fixtureKey{group: group, song: song}
+	_, ok := s.fixtures[key]
+	delete(s.fixtures, key)
+	return ok
+}
+
+func (s *FixtureStore) Reset(fixtures []Fixture) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fixtures = make(map[fixtureKey]Fixture, len(fixtures))
+	for _, fixture := range fixtures {
+		s.fixtures[fixtureKey{group: fixture.Group, song: fixture.Song}] = fixture
+	}
+}
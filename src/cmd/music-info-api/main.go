@@ -1,15 +1,63 @@
 package main
 
 import (
+	"fmt"
 	"github.com/labstack/echo/v4"
 	"log"
 	"net/http"
 	"song-library-api/src/pkg/music_info_client/model"
 )
 
+const maxBatchSize = 100
+
 func main() {
+	cfg, err := FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	faults := NewFaultInjector(Faults{
+		Latency:        Duration(cfg.Latency),
+		LatencyJitter:  Duration(cfg.LatencyJitter),
+		ErrorRate:      cfg.ErrorRate,
+		RateLimit:      cfg.RateLimit,
+		RateLimitBurst: cfg.RateLimitBurst,
+	})
+
 	e := echo.New()
+	e.Use(NewAuthMiddleware(cfg))
+	e.GET("/health", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, faults.Middleware)
+
+	switch cfg.Mode {
+	case ModeFake:
+		fixtures := defaultFixtures
+		if cfg.FixturesPath != "" {
+			if fixtures, err = LoadFixtures(cfg.FixturesPath); err != nil {
+				log.Fatal(err)
+			}
+		}
+		initFakeRoutes(e, NewFixtureStore(fixtures), faults)
+	case ModeRecord, ModeReplay:
+		recordings, err := NewRecordingStore(cfg.RecordingsDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		handler := NewReplayHandler(recordings)
+		if cfg.Mode == ModeRecord {
+			handler = NewRecordHandler(recordings, cfg.UpstreamURL, cfg.RecordErrors)
+		}
+		e.Any("/*", handler, faults.Middleware)
+	}
+
+	if err := e.Start(cfg.Address); err != nil {
+		log.Fatal(err)
+	}
+}
 
+func initFakeRoutes(e *echo.Echo, store *FixtureStore, faults *FaultInjector) {
 	e.GET("/info", func(c echo.Context) error {
 		group := c.QueryParam("group")
 		song := c.QueryParam("song")
@@ -20,22 +68,57 @@ func main() {
 			})
 		}
 
-		if group == "500" {
-			return echo.ErrInternalServerError
+		fixture, ok := store.Get(group, song)
+		if !ok {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Song not found",
+			})
 		}
 
-		if group == "400" {
+		return c.JSON(http.StatusOK, fixture.SongDetail)
+	}, faults.Middleware)
+
+	e.POST("/info/batch", func(c echo.Context) error {
+		var queries []model.SongQuery
+		if err := c.Bind(&queries); err != nil {
 			return echo.ErrBadRequest
 		}
 
-		return c.JSON(http.StatusOK, model.SongDetail{
-			ReleaseDate: "16.07.2006",
-			Text:        "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight",
-			Link:        "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
-		})
-	})
+		if len(queries) > maxBatchSize {
+			return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{
+				"error": fmt.Sprintf("At most %d songs can be requested at once", maxBatchSize),
+			})
+		}
 
-	if err := e.Start("0.0.0.0:8081"); err != nil {
-		log.Fatal(err)
-	}
+		results := make([]model.SongInfoResult, 0, len(queries))
+		for _, query := range queries {
+			result := model.SongInfoResult{Group: query.Group, Song: query.Song}
+
+			fixture, ok := store.Get(query.Group, query.Song)
+			switch {
+			case query.Group == "" || query.Song == "":
+				result.Status = http.StatusBadRequest
+				result.Error = "Both 'group' and 'song' fields are required"
+			case !ok:
+				result.Status = http.StatusNotFound
+				result.Error = "Song not found"
+			default:
+				result.Status = http.StatusOK
+				result.SongDetail = &fixture.SongDetail
+			}
+
+			results = append(results, result)
+		}
+
+		return c.JSON(http.StatusOK, results)
+	}, faults.Middleware)
+
+	admin := NewAdminController(store, faults)
+	g := e.Group("/admin")
+	g.GET("/fixtures", admin.ListFixtures)
+	g.POST("/fixtures", admin.AddFixtures)
+	g.DELETE("/fixtures", admin.DeleteFixture)
+	g.DELETE("/fixtures/all", admin.ResetFixtures)
+	g.GET("/faults", admin.GetFaults)
+	g.PUT("/faults", admin.SetFaults)
 }
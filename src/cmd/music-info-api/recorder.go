@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Recording struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query"`
+	Body   string `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// hopHeaders are not forwarded by the recording proxy.
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade", "Content-Length",
+}
+
+// RecordingStore keeps one file per distinct request. Requests are matched
+// by method, path, sorted query and body, so replays are deterministic.
+type RecordingStore struct {
+	dir string
+}
+
+func NewRecordingStore(dir string) (*RecordingStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "failed to create recordings directory")
+	}
+	return &RecordingStore{dir: dir}, nil
+}
+
+func (s *RecordingStore) Load(request RecordedRequest) (*Recording, error) {
+	data, err := os.ReadFile(s.path(request))
+	if err != nil {
+		return nil, err
+	}
+
+	var recording Recording
+	if err = json.Unmarshal(data, &recording); err != nil {
+		return nil, errors.Wrap(err, "failed to decode recording")
+	}
+
+	return &recording, nil
+}
+
+func (s *RecordingStore) Save(recording Recording) error {
+	data, err := json.MarshalIndent(recording, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode recording")
+	}
+
+	return os.WriteFile(s.path(recording.Request), data, 0o644)
+}
+
+func (s *RecordingStore) path(request RecordedRequest) string {
+	hash := sha256.Sum256([]byte(strings.Join([]string{
+		request.Method, request.Path, request.Query, request.Body,
+	}, "\n")))
+	return filepath.Join(s.dir, hex.EncodeToString(hash[:8])+".json")
+}
+
+func toRecordedRequest(r *http.Request) (RecordedRequest, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return RecordedRequest{}, errors.Wrap(err, "failed to read request body")
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	return RecordedRequest{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		// Encode sorts the parameters by key.
+		Query: r.URL.Query().Encode(),
+		Body:  string(body),
+	}, nil
+}
+
+// NewRecordHandler proxies requests upstream and records the responses. Only
+// 2xx responses are recorded unless recordErrors is set, so that a failing
+// upstream does not overwrite good recordings.
+func NewRecordHandler(store *RecordingStore, upstreamURL string, recordErrors bool) echo.HandlerFunc {
+	client := &http.Client{}
+
+	return func(c echo.Context) error {
+		recorded, err := toRecordedRequest(c.Request())
+		if err != nil {
+			return err
+		}
+
+		target := strings.TrimSuffix(upstreamURL, "/") + recorded.Path
+		if recorded.Query != "" {
+			target += "?" + recorded.Query
+		}
+
+		request, err := http.NewRequestWithContext(c.Request().Context(),
+			recorded.Method, target, strings.NewReader(recorded.Body))
+		if err != nil {
+			return errors.Wrap(err, "failed to create upstream request")
+		}
+		request.Header = c.Request().Header.Clone()
+		for _, header := range hopHeaders {
+			request.Header.Del(header)
+		}
+
+		response, err := client.Do(request)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadGateway, err.Error())
+		}
+		defer response.Body.Close()
+
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadGateway, err.Error())
+		}
+
+		recording := Recording{
+			Request: recorded,
+			Response: RecordedResponse{
+				Status: response.StatusCode,
+				Header: response.Header.Clone(),
+				Body:   string(body),
+			},
+		}
+		for _, header := range hopHeaders {
+			recording.Response.Header.Del(header)
+		}
+		if recordErrors || isSuccess(response.StatusCode) {
+			if err = store.Save(recording); err != nil {
+				return err
+			}
+		}
+
+		return writeRecordedResponse(c, recording.Response)
+	}
+}
+
+func isSuccess(status int) bool {
+	return status >= http.StatusOK && status < http.StatusMultipleChoices
+}
+
+func NewReplayHandler(store *RecordingStore) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		recorded, err := toRecordedRequest(c.Request())
+		if err != nil {
+			return err
+		}
+
+		recording, err := store.Load(recorded)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return c.JSON(http.StatusNotFound, map[string]string{
+					"error": "No recording for this request",
+				})
+			}
+			return err
+		}
+
+		return writeRecordedResponse(c, recording.Response)
+	}
+}
+
+func writeRecordedResponse(c echo.Context, response RecordedResponse) error {
+	for name, values := range response.Header {
+		for _, value := range values {
+			c.Response().Header().Add(name, value)
+		}
+	}
+	c.Response().WriteHeader(response.Status)
+	_, err := c.Response().Write([]byte(response.Body))
+	return err
+}
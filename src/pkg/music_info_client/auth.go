@@ -0,0 +1,89 @@
+package music_info_client
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderSignatureKeyID     = "X-Signature-Key-Id"
+	HeaderSignatureTimestamp = "X-Signature-Timestamp"
+	HeaderSignature          = "X-Signature"
+)
+
+var ErrInvalidSignature = errors.New("invalid request signature")
+
+// SignRequest adds an HMAC-SHA256 signature of the method, path, query,
+// timestamp and body hash. The request body is restored after hashing.
+func SignRequest(r *http.Request, keyID, secret string, now time.Time) error {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature, err := requestSignature(r, secret, timestamp)
+	if err != nil {
+		return err
+	}
+
+	r.Header.Set(HeaderSignatureKeyID, keyID)
+	r.Header.Set(HeaderSignatureTimestamp, timestamp)
+	r.Header.Set(HeaderSignature, signature)
+	return nil
+}
+
+// VerifyRequest checks a signature made by SignRequest. secretFor returns the
+// secret of a key id and false for unknown keys.
+func VerifyRequest(r *http.Request, secretFor func(keyID string) (string, bool), maxSkew time.Duration, now time.Time) error {
+	secret, ok := secretFor(r.Header.Get(HeaderSignatureKeyID))
+	if !ok {
+		return errors.Wrap(ErrInvalidSignature, "unknown key id")
+	}
+
+	timestamp := r.Header.Get(HeaderSignatureTimestamp)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.Wrap(ErrInvalidSignature, "malformed timestamp")
+	}
+	if skew := now.Sub(time.Unix(unix, 0)); skew > maxSkew || skew < -maxSkew {
+		return errors.Wrap(ErrInvalidSignature, "timestamp out of range")
+	}
+
+	expected, err := requestSignature(r, secret, timestamp)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get(HeaderSignature))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func requestSignature(r *http.Request, secret, timestamp string) (string, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return "", errors.Wrap(err, "failed to read request body")
+		}
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	bodyHash := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join([]string{
+		r.Method,
+		r.URL.Path,
+		r.URL.Query().Encode(),
+		timestamp,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")))
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,149 @@
+package music_info_client
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignRequest_roundTrip(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	secrets := map[string]string{"key-1": "secret"}
+	secretFor := func(keyID string) (string, bool) {
+		secret, ok := secrets[keyID]
+		return secret, ok
+	}
+
+	tests := []struct {
+		name    string
+		keyID   string
+		secret  string
+		tamper  func(r *http.Request)
+		at      time.Time
+		wantErr bool
+	}{
+		{name: "valid", keyID: "key-1", secret: "secret", at: now},
+		{name: "within skew", keyID: "key-1", secret: "secret", at: now.Add(4 * time.Minute)},
+		{name: "expired", keyID: "key-1", secret: "secret", at: now.Add(6 * time.Minute), wantErr: true},
+		{name: "from the future", keyID: "key-1", secret: "secret", at: now.Add(-6 * time.Minute), wantErr: true},
+		{name: "unknown key", keyID: "key-2", secret: "secret", at: now, wantErr: true},
+		{name: "wrong secret", keyID: "key-1", secret: "other", at: now, wantErr: true},
+		{
+			name: "changed query", keyID: "key-1", secret: "secret", at: now, wantErr: true,
+			tamper: func(r *http.Request) { r.URL.RawQuery = "group=Muse&song=Uprising" },
+		},
+		{
+			name: "changed path", keyID: "key-1", secret: "secret", at: now, wantErr: true,
+			tamper: func(r *http.Request) { r.URL.Path = "/info/batch" },
+		},
+		{
+			name: "changed body", keyID: "key-1", secret: "secret", at: now, wantErr: true,
+			tamper: func(r *http.Request) { r.Body = io.NopCloser(strings.NewReader(`{"song":"Uprising"}`)) },
+		},
+		{
+			name: "malformed timestamp", keyID: "key-1", secret: "secret", at: now, wantErr: true,
+			tamper: func(r *http.Request) { r.Header.Set(HeaderSignatureTimestamp, "yesterday") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/info?group=Muse&song=Hysteria", strings.NewReader(`{"song":"Hysteria"}`))
+			if err := SignRequest(request, tt.keyID, tt.secret, now); err != nil {
+				t.Fatalf("SignRequest() error = %v", err)
+			}
+
+			body, _ := io.ReadAll(request.Body)
+			if string(body) != `{"song":"Hysteria"}` {
+				t.Fatalf("body after signing = %q, want it restored", body)
+			}
+			request.Body = io.NopCloser(strings.NewReader(string(body)))
+			if tt.tamper != nil {
+				tt.tamper(request)
+			}
+
+			err := VerifyRequest(request, secretFor, 5*time.Minute, tt.at)
+			if tt.wantErr && !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("VerifyRequest() error = %v, want ErrInvalidSignature", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("VerifyRequest() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestSignRequest_noBody(t *testing.T) {
+	now := time.Now()
+	request := httptest.NewRequest(http.MethodGet, "/info?group=Muse&song=Hysteria", nil)
+	request.Body = nil
+
+	if err := SignRequest(request, "key-1", "secret", now); err != nil {
+		t.Fatalf("SignRequest() error = %v", err)
+	}
+	err := VerifyRequest(request, func(string) (string, bool) { return "secret", true }, time.Minute, now)
+	if err != nil {
+		t.Errorf("VerifyRequest() error = %v", err)
+	}
+}
+
+func TestMusicInfoClient_credentials(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want map[string]string
+	}{
+		{
+			name: "api key in the default header",
+			opts: []Option{WithAPIKey("", "k")},
+			want: map[string]string{"X-API-Key": "k"},
+		},
+		{
+			name: "api key in a custom header",
+			opts: []Option{WithAPIKey("X-Token", "k")},
+			want: map[string]string{"X-Token": "k", "X-API-Key": ""},
+		},
+		{
+			name: "bearer token",
+			opts: []Option{WithBearerToken("t")},
+			want: map[string]string{"Authorization": "Bearer t"},
+		},
+		{
+			name: "hmac signature",
+			opts: []Option{WithHMACSigning("key-1", "secret")},
+			want: map[string]string{HeaderSignatureKeyID: "key-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got http.Header
+			var verifyErr error
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Clone()
+				if r.Header.Get(HeaderSignature) != "" {
+					verifyErr = VerifyRequest(r, func(string) (string, bool) { return "secret", true }, time.Minute, time.Now())
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			if err := NewMusicInfoClient(server.URL, tt.opts...).Ping(context.Background()); err != nil {
+				t.Fatalf("Ping() error = %v", err)
+			}
+			for name, value := range tt.want {
+				if got.Get(name) != value {
+					t.Errorf("%s = %q, want %q", name, got.Get(name), value)
+				}
+			}
+			if verifyErr != nil {
+				t.Errorf("VerifyRequest() error = %v", verifyErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package music_info_client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"net/http"
+	"song-library-api/src/pkg/music_info_client/model"
+	"sync"
+	"time"
+)
+
+type Query = model.SongQuery
+
+type Result struct {
+	Query      Query
+	SongDetail *model.SongDetail
+	Err        error
+}
+
+// GetSongsInfo looks up many songs with at most the configured number of
+// requests in flight. Results are returned in query order, each with its own error.
+func (c *MusicInfoClient) GetSongsInfo(ctx context.Context, queries []Query) []Result {
+	results := make([]Result, len(queries))
+	for i, query := range queries {
+		results[i].Query = query
+	}
+
+	if c.batchSize > 0 {
+		c.getSongsInfoBatched(ctx, results)
+	} else {
+		c.forEachLimited(len(results), func(i int) {
+			query := results[i].Query
+			results[i].SongDetail, results[i].Err = c.GetSongInfo(ctx, query.Group, query.Song)
+		})
+	}
+
+	return results
+}
+
+func (c *MusicInfoClient) forEachLimited(n int, fn func(i int)) {
+	sem := make(chan struct{}, c.batchConcurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
+
+// getSongsInfoBatched serves what it can from the cache and sends the rest
+// to the batch endpoint in chunks.
+func (c *MusicInfoClient) getSongsInfoBatched(ctx context.Context, results []Result) {
+	misses := make([]int, 0, len(results))
+	for i := range results {
+		if c.cache != nil && !revalidating(ctx) {
+			query := results[i].Query
+			cached, err := c.cache.Get(ctx, cacheKey(query.Group, query.Song))
+			c.logCacheError(ctx, "failed to read music info cache", err)
+			if cached != nil && cached.Fresh(time.Now()) {
+				if cached.NotFound {
+					results[i].Err = ErrSongNotFound
+				} else {
+					results[i].SongDetail = cached.SongDetail
+				}
+				continue
+			}
+		}
+		misses = append(misses, i)
+	}
+
+	chunks := make([][]int, 0, len(misses)/c.batchSize+1)
+	for start := 0; start < len(misses); start += c.batchSize {
+		chunks = append(chunks, misses[start:min(start+c.batchSize, len(misses))])
+	}
+
+	c.forEachLimited(len(chunks), func(i int) {
+		chunk := chunks[i]
+		queries := make([]Query, 0, len(chunk))
+		for _, index := range chunk {
+			queries = append(queries, results[index].Query)
+		}
+
+		items, cc, err := c.fetchSongsInfo(ctx, queries)
+		for j, index := range chunk {
+			if err != nil {
+				results[index].Err = err
+				continue
+			}
+			results[index].SongDetail, results[index].Err = c.storeBatchItem(ctx, items[j], cc)
+		}
+	})
+}
+
+// storeBatchItem caches the item like a single lookup. The Cache-Control of
+// the batch response applies to every item, the ETag comes with the item.
+func (c *MusicInfoClient) storeBatchItem(ctx context.Context, item model.SongInfoResult, cc cacheControl) (*model.SongDetail, error) {
+	store := func(entry CacheEntry, ttl time.Duration) {
+		if c.cache == nil || cc.noStore {
+			return
+		}
+		entry.ETag = item.ETag
+		entry.ExpiresAt = time.Now().Add(ttl)
+		c.logCacheError(ctx, "failed to write music info cache", c.cache.Set(ctx, cacheKey(item.Group, item.Song), entry))
+	}
+
+	switch item.Status {
+	case http.StatusOK:
+		store(CacheEntry{SongDetail: item.SongDetail}, cc.ttl(c.cacheTTL))
+		return item.SongDetail, nil
+	case http.StatusNotFound:
+		store(CacheEntry{NotFound: true}, cc.ttl(c.negativeCacheTTL))
+		return nil, ErrSongNotFound
+	default:
+		return nil, errors.Errorf("request failed with status code %d: %s", item.Status, item.Error)
+	}
+}
+
+func (c *MusicInfoClient) fetchSongsInfo(ctx context.Context, queries []Query) ([]model.SongInfoResult, cacheControl, error) {
+	body, err := json.Marshal(queries)
+	if err != nil {
+		return nil, cacheControl{}, errors.Wrap(err, "failed to encode batch request")
+	}
+
+	response, err := c.do(ctx, func() (*http.Request, error) {
+		request, err := http.NewRequestWithContext(ctx, http.MethodPost,
+			fmt.Sprintf("%s/info/batch", c.baseUrl), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set("Content-Type", "application/json")
+		return request, nil
+	})
+	if err != nil {
+		return nil, cacheControl{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, cacheControl{}, errors.Errorf("request failed with status code %d", response.StatusCode)
+	}
+
+	var items []model.SongInfoResult
+	if err := json.NewDecoder(response.Body).Decode(&items); err != nil {
+		return nil, cacheControl{}, fmt.Errorf("failed to decode JSON response: %w", err)
+	}
+	if len(items) != len(queries) {
+		return nil, cacheControl{}, errors.Errorf("batch response has %d items, expected %d", len(items), len(queries))
+	}
+
+	return items, parseCacheControl(response.Header), nil
+}
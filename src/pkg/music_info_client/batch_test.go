@@ -0,0 +1,153 @@
+package music_info_client
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"song-library-api/src/pkg/music_info_client/model"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// batchServer answers every query of a batch with the status of its song,
+// 200 unless listed, and records the size of each batch.
+func batchServer(t *testing.T, statuses map[string]int) (*httptest.Server, *[]int) {
+	t.Helper()
+
+	var mu sync.Mutex
+	sizes := make([]int, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var queries []Query
+		if err := json.NewDecoder(r.Body).Decode(&queries); err != nil {
+			t.Errorf("failed to decode batch: %v", err)
+		}
+
+		mu.Lock()
+		sizes = append(sizes, len(queries))
+		mu.Unlock()
+
+		if status, ok := statuses["*"]; ok {
+			w.WriteHeader(status)
+			return
+		}
+
+		items := make([]model.SongInfoResult, 0, len(queries))
+		for _, query := range queries {
+			item := model.SongInfoResult{Group: query.Group, Song: query.Song, Status: http.StatusOK}
+			if status, ok := statuses[query.Song]; ok {
+				item.Status, item.Error = status, http.StatusText(status)
+			} else {
+				item.SongDetail = &model.SongDetail{Text: query.Song}
+			}
+			items = append(items, item)
+		}
+		_ = json.NewEncoder(w).Encode(items)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &sizes
+}
+
+func queries(songs ...string) []Query {
+	result := make([]Query, 0, len(songs))
+	for _, song := range songs {
+		result = append(result, Query{Group: "Muse", Song: song})
+	}
+	return result
+}
+
+func TestMusicInfoClient_GetSongsInfo_chunks(t *testing.T) {
+	tests := []struct {
+		name      string
+		batchSize int
+		songs     int
+		wantSizes []int
+	}{
+		{name: "empty", batchSize: 2, songs: 0, wantSizes: []int{}},
+		{name: "one chunk", batchSize: 5, songs: 3, wantSizes: []int{3}},
+		{name: "exact chunks", batchSize: 2, songs: 4, wantSizes: []int{2, 2}},
+		{name: "last chunk smaller", batchSize: 2, songs: 5, wantSizes: []int{1, 2, 2}},
+		{name: "size clamped", batchSize: MaxBatchSize * 2, songs: MaxBatchSize + 1, wantSizes: []int{1, MaxBatchSize}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, sizes := batchServer(t, nil)
+			client := NewMusicInfoClient(server.URL, WithBatchEndpoint(tt.batchSize), WithBatchConcurrency(2))
+
+			songs := make([]string, tt.songs)
+			for i := range songs {
+				songs[i] = strings.Repeat("a", i+1)
+			}
+			results := client.GetSongsInfo(context.Background(), queries(songs...))
+
+			// Chunks are sent concurrently, their order is not fixed.
+			got := append([]int{}, *sizes...)
+			slices.Sort(got)
+			if !reflect.DeepEqual(got, tt.wantSizes) {
+				t.Errorf("batch sizes = %v, want %v", got, tt.wantSizes)
+			}
+			for i, result := range results {
+				if result.Err != nil || result.Query.Song != songs[i] || result.SongDetail.Text != songs[i] {
+					t.Errorf("result %d = %+v, want the detail of %s", i, result, songs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMusicInfoClient_GetSongsInfo_itemErrors(t *testing.T) {
+	server, _ := batchServer(t, map[string]int{
+		"Unknown": http.StatusNotFound,
+		"Invalid": http.StatusBadRequest,
+	})
+	client := NewMusicInfoClient(server.URL, WithBatchEndpoint(10))
+
+	results := client.GetSongsInfo(context.Background(), queries("Hysteria", "Unknown", "Invalid"))
+
+	if results[0].Err != nil || results[0].SongDetail == nil {
+		t.Errorf("Hysteria = %+v, want its detail", results[0])
+	}
+	if !errors.Is(results[1].Err, ErrSongNotFound) || results[1].SongDetail != nil {
+		t.Errorf("Unknown = %+v, want ErrSongNotFound", results[1])
+	}
+	if results[2].Err == nil || !strings.Contains(results[2].Err.Error(), "400") {
+		t.Errorf("Invalid = %+v, want the status of the item", results[2])
+	}
+}
+
+func TestMusicInfoClient_GetSongsInfo_failedChunk(t *testing.T) {
+	server, _ := batchServer(t, map[string]int{"*": http.StatusServiceUnavailable})
+	client := NewMusicInfoClient(server.URL, WithBatchEndpoint(2))
+
+	results := client.GetSongsInfo(context.Background(), queries("a", "b", "c"))
+
+	for i, result := range results {
+		if result.Err == nil || !strings.Contains(result.Err.Error(), "503") {
+			t.Errorf("result %d error = %v, want the status of the batch", i, result.Err)
+		}
+	}
+}
+
+func TestMusicInfoClient_GetSongsInfo_cachedItemsSkipped(t *testing.T) {
+	server, sizes := batchServer(t, nil)
+	client := NewMusicInfoClient(server.URL, WithBatchEndpoint(10), WithCache(NewLRUCache(10), time.Hour, time.Hour))
+
+	client.GetSongsInfo(context.Background(), queries("a", "b"))
+	results := client.GetSongsInfo(context.Background(), queries("a", "b", "c"))
+
+	if !reflect.DeepEqual(*sizes, []int{2, 1}) {
+		t.Errorf("batch sizes = %v, want [2 1]", *sizes)
+	}
+	for i, result := range results {
+		if result.Err != nil || result.SongDetail == nil {
+			t.Errorf("result %d = %+v, want a detail", i, result)
+		}
+	}
+}
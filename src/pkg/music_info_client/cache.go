@@ -0,0 +1,130 @@
+package music_info_client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"song-library-api/src/pkg/music_info_client/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cache stores song info responses keyed by group and song.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(ctx context.Context, key string) (*CacheEntry, error)
+	Set(ctx context.Context, key string, entry CacheEntry) error
+}
+
+type CacheEntry struct {
+	SongDetail *model.SongDetail
+	// NotFound marks a cached negative result.
+	NotFound  bool
+	ETag      string
+	ExpiresAt time.Time
+}
+
+func (e *CacheEntry) Fresh(now time.Time) bool {
+	return now.Before(e.ExpiresAt)
+}
+
+type revalidateKey struct{}
+
+// Revalidate makes the lookups made with the returned context skip fresh
+// cache entries. Cached ETags are still sent, so unchanged songs cost a
+// not modified response.
+func Revalidate(ctx context.Context) context.Context {
+	return context.WithValue(ctx, revalidateKey{}, true)
+}
+
+func revalidating(ctx context.Context) bool {
+	revalidate, _ := ctx.Value(revalidateKey{}).(bool)
+	return revalidate
+}
+
+// cacheKey hashes the names, which may hold any character, NUL bytes
+// included, that a Postgres text key cannot store. The group is prefixed
+// with its length, so that no two pairs of names share a key.
+func cacheKey(group, song string) string {
+	sum := sha256.Sum256([]byte(strconv.Itoa(len(group)) + ":" + group + song))
+	return hex.EncodeToString(sum[:])
+}
+
+type cacheControl struct {
+	noStore bool
+	noCache bool
+	maxAge  *time.Duration
+}
+
+func parseCacheControl(header http.Header) cacheControl {
+	var cc cacheControl
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store":
+			cc.noStore = true
+		case "no-cache":
+			cc.noCache = true
+		case "max-age":
+			if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && seconds >= 0 {
+				maxAge := time.Duration(seconds) * time.Second
+				cc.maxAge = &maxAge
+			}
+		}
+	}
+	return cc
+}
+
+// ttl returns how long a response may be served without revalidation.
+func (cc cacheControl) ttl(fallback time.Duration) time.Duration {
+	switch {
+	case cc.noCache:
+		return 0
+	case cc.maxAge != nil:
+		return *cc.maxAge
+	default:
+		return fallback
+	}
+}
+
+var _ Cache = (*TieredCache)(nil)
+
+// TieredCache reads through a fast cache into a slower shared one and
+// promotes entries found in the latter. An expired fast entry is looked up
+// in the shared cache too, another instance may have refreshed it since.
+type TieredCache struct {
+	fast Cache
+	slow Cache
+}
+
+func NewTieredCache(fast, slow Cache) *TieredCache {
+	return &TieredCache{
+		fast: fast,
+		slow: slow,
+	}
+}
+
+func (c *TieredCache) Get(ctx context.Context, key string) (*CacheEntry, error) {
+	entry, err := c.fast.Get(ctx, key)
+	if err != nil || entry != nil && entry.Fresh(time.Now()) {
+		return entry, err
+	}
+
+	// The expired fast entry is still returned if the shared cache has
+	// nothing newer, its ETag can revalidate it.
+	shared, err := c.slow.Get(ctx, key)
+	if err != nil || shared == nil || entry != nil && !shared.ExpiresAt.After(entry.ExpiresAt) {
+		return entry, err
+	}
+
+	return shared, c.fast.Set(ctx, key, *shared)
+}
+
+func (c *TieredCache) Set(ctx context.Context, key string, entry CacheEntry) error {
+	if err := c.fast.Set(ctx, key, entry); err != nil {
+		return err
+	}
+	return c.slow.Set(ctx, key, entry)
+}
@@ -0,0 +1,270 @@
+package music_info_client
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"song-library-api/src/pkg/music_info_client/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+// respond writes the song detail with the given headers, or 404 for a nil detail.
+func respond(headers map[string]string, detail *model.SongDetail) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for name, value := range headers {
+			w.Header().Set(name, value)
+		}
+		if detail == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(detail)
+	}
+}
+
+func notModified(headers map[string]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for name, value := range headers {
+			w.Header().Set(name, value)
+		}
+		w.WriteHeader(http.StatusNotModified)
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	key := cacheKey("Muse", "Hysteria")
+	if len(key) != 64 || strings.ContainsRune(key, 0) {
+		t.Errorf("cacheKey() = %q, want a hex SHA-256", key)
+	}
+	if cacheKey("a", "bc") == cacheKey("ab", "c") {
+		t.Error("cacheKey() is the same for different names")
+	}
+	if cacheKey("a\x00b", "c") == cacheKey("a", "b\x00c") {
+		t.Error("cacheKey() is the same for names with NUL bytes")
+	}
+}
+
+func TestMusicInfoClient_GetSongInfo_cache(t *testing.T) {
+	detail := &model.SongDetail{Text: "Verse", Link: "https://example.com", ReleaseDate: "16.07.2006"}
+
+	tests := []struct {
+		name        string
+		responses   []http.HandlerFunc
+		revalidate  bool
+		lookups     int
+		wantETags   []string
+		wantErr     error
+		wantDetails bool
+	}{
+		{
+			name:        "fresh entry is served from the cache",
+			responses:   []http.HandlerFunc{respond(nil, detail)},
+			lookups:     2,
+			wantETags:   []string{""},
+			wantDetails: true,
+		},
+		{
+			name: "expired entry is revalidated with its ETag",
+			responses: []http.HandlerFunc{
+				respond(map[string]string{"ETag": `"v1"`, "Cache-Control": "max-age=0"}, detail),
+				notModified(nil),
+			},
+			lookups:     2,
+			wantETags:   []string{"", `"v1"`},
+			wantDetails: true,
+		},
+		{
+			name: "no-cache revalidates every lookup",
+			responses: []http.HandlerFunc{
+				respond(map[string]string{"ETag": `"v1"`, "Cache-Control": "no-cache"}, detail),
+				notModified(map[string]string{"Cache-Control": "no-cache"}),
+				notModified(map[string]string{"Cache-Control": "no-cache"}),
+			},
+			lookups:     3,
+			wantETags:   []string{"", `"v1"`, `"v1"`},
+			wantDetails: true,
+		},
+		{
+			name: "no-store is not cached",
+			responses: []http.HandlerFunc{
+				respond(map[string]string{"ETag": `"v1"`, "Cache-Control": "no-store"}, detail),
+				respond(nil, detail),
+			},
+			lookups:     2,
+			wantETags:   []string{"", ""},
+			wantDetails: true,
+		},
+		{
+			name:        "revalidate skips fresh entries",
+			responses:   []http.HandlerFunc{respond(map[string]string{"ETag": `"v1"`}, detail), notModified(nil)},
+			revalidate:  true,
+			lookups:     2,
+			wantETags:   []string{"", `"v1"`},
+			wantDetails: true,
+		},
+		{
+			name:      "unknown song is cached",
+			responses: []http.HandlerFunc{respond(nil, nil)},
+			lookups:   2,
+			wantETags: []string{""},
+			wantErr:   ErrSongNotFound,
+		},
+		{
+			name: "expired unknown song is revalidated",
+			responses: []http.HandlerFunc{
+				respond(map[string]string{"ETag": `"gone"`, "Cache-Control": "max-age=0"}, nil),
+				notModified(nil),
+			},
+			lookups:   2,
+			wantETags: []string{"", `"gone"`},
+			wantErr:   ErrSongNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			etags := make([]string, 0)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				etags = append(etags, r.Header.Get("If-None-Match"))
+				if len(etags) > len(tt.responses) {
+					t.Errorf("unexpected request %d", len(etags))
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				tt.responses[len(etags)-1](w, r)
+			}))
+			defer server.Close()
+
+			client := NewMusicInfoClient(server.URL, WithCache(NewLRUCache(10), time.Hour, time.Hour))
+			ctx := context.Background()
+			if tt.revalidate {
+				ctx = Revalidate(ctx)
+			}
+
+			for i := 0; i < tt.lookups; i++ {
+				got, err := client.GetSongInfo(ctx, "Muse", "Hysteria")
+				if tt.wantErr != nil {
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("lookup %d error = %v, want %v", i, err, tt.wantErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("lookup %d error = %v", i, err)
+				}
+				if tt.wantDetails && !reflect.DeepEqual(got, detail) {
+					t.Errorf("lookup %d = %+v, want %+v", i, got, detail)
+				}
+			}
+
+			if !reflect.DeepEqual(etags, tt.wantETags) {
+				t.Errorf("If-None-Match of the requests = %q, want %q", etags, tt.wantETags)
+			}
+		})
+	}
+}
+
+func TestTieredCache_Get(t *testing.T) {
+	now := time.Now()
+	fresh := CacheEntry{ETag: "fresh", ExpiresAt: now.Add(time.Hour)}
+	newer := CacheEntry{ETag: "newer", ExpiresAt: now.Add(-time.Minute)}
+	expired := CacheEntry{ETag: "expired", ExpiresAt: now.Add(-time.Hour)}
+
+	tests := []struct {
+		name      string
+		fast      *CacheEntry
+		slow      *CacheEntry
+		want      string
+		wantFast  string
+		wantFound bool
+	}{
+		{name: "miss"},
+		{name: "fresh fast entry", fast: &fresh, slow: &expired, want: "fresh", wantFast: "fresh", wantFound: true},
+		{name: "promoted from slow", slow: &fresh, want: "fresh", wantFast: "fresh", wantFound: true},
+		{name: "expired fast entry refreshed in slow", fast: &expired, slow: &fresh, want: "fresh", wantFast: "fresh", wantFound: true},
+		{name: "both expired, slow newer", fast: &expired, slow: &newer, want: "newer", wantFast: "newer", wantFound: true},
+		{name: "expired fast entry, slow older", fast: &newer, slow: &expired, want: "newer", wantFast: "newer", wantFound: true},
+		{name: "expired fast entry, slow missing", fast: &expired, want: "expired", wantFast: "expired", wantFound: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			fast, slow := NewLRUCache(10), NewLRUCache(10)
+			if tt.fast != nil {
+				_ = fast.Set(ctx, "key", *tt.fast)
+			}
+			if tt.slow != nil {
+				_ = slow.Set(ctx, "key", *tt.slow)
+			}
+
+			got, err := NewTieredCache(fast, slow).Get(ctx, "key")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if (got != nil) != tt.wantFound || got != nil && got.ETag != tt.want {
+				t.Fatalf("Get() = %+v, want %q", got, tt.want)
+			}
+
+			promoted, _ := fast.Get(ctx, "key")
+			if tt.wantFast != "" && (promoted == nil || promoted.ETag != tt.wantFast) {
+				t.Errorf("fast entry = %+v, want %q", promoted, tt.wantFast)
+			}
+		})
+	}
+}
+
+func TestMusicInfoClient_GetSongsInfo_batchCache(t *testing.T) {
+	detail := &model.SongDetail{Text: "Verse"}
+
+	tests := []struct {
+		name         string
+		cacheControl string
+		wantCached   bool
+		wantTTL      time.Duration
+	}{
+		{name: "default ttl", wantCached: true, wantTTL: time.Hour},
+		{name: "max-age", cacheControl: "max-age=60", wantCached: true, wantTTL: time.Minute},
+		{name: "no-store", cacheControl: "no-store"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.cacheControl != "" {
+					w.Header().Set("Cache-Control", tt.cacheControl)
+				}
+				_ = json.NewEncoder(w).Encode([]model.SongInfoResult{
+					{Group: "Muse", Song: "Hysteria", Status: http.StatusOK, SongDetail: detail, ETag: `"v1"`},
+					{Group: "Muse", Song: "Unknown", Status: http.StatusNotFound, ETag: `"gone"`},
+				})
+			}))
+			defer server.Close()
+
+			cache := NewLRUCache(10)
+			client := NewMusicInfoClient(server.URL, WithCache(cache, time.Hour, time.Hour), WithBatchEndpoint(10))
+			client.GetSongsInfo(context.Background(), []Query{{Group: "Muse", Song: "Hysteria"}, {Group: "Muse", Song: "Unknown"}})
+
+			for song, etag := range map[string]string{"Hysteria": `"v1"`, "Unknown": `"gone"`} {
+				entry, _ := cache.Get(context.Background(), cacheKey("Muse", song))
+				if !tt.wantCached {
+					if entry != nil {
+						t.Errorf("%s cached as %+v, want nothing cached", song, entry)
+					}
+					continue
+				}
+				if entry == nil {
+					t.Fatalf("%s not cached", song)
+				}
+				if ttl := time.Until(entry.ExpiresAt); entry.ETag != etag || ttl > tt.wantTTL || ttl < tt.wantTTL-time.Minute/2 {
+					t.Errorf("%s cached with %q for %s, want %q for %s", song, entry.ETag, ttl, etag, tt.wantTTL)
+				}
+			}
+		})
+	}
+}
@@ -5,27 +5,130 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"song-library-api/src/pkg/music_info_client/model"
+	"sync/atomic"
+	"time"
 )
 
+var ErrSongNotFound = errors.New("song not found")
+
 type MusicInfoClient struct {
 	baseUrl    string
+	healthPath string
 	httpClient *http.Client
+
+	cache            Cache
+	cacheTTL         time.Duration
+	negativeCacheTTL time.Duration
+
+	batchConcurrency int
+	batchSize        int
+
+	limiter      *rate.Limiter
+	maxRetries   int
+	blockedUntil atomic.Int64
+	stats        stats
+
+	timeout        time.Duration
+	baseTransport  http.RoundTripper
+	tracing        bool
+	headers        map[string]string
+	authenticators []func(*http.Request) error
+	requestHooks   []RequestHook
+	responseHooks  []ResponseHook
+	logger         *slog.Logger
+}
+
+func NewMusicInfoClient(baseUrl string, opts ...Option) *MusicInfoClient {
+	c := &MusicInfoClient{
+		baseUrl:          baseUrl,
+		healthPath:       "/health",
+		batchConcurrency: 4,
+		maxRetries:       3,
+		headers:          make(map[string]string),
+		logger:           slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.httpClient = &http.Client{Transport: c.transport(c.baseTransport), Timeout: c.timeout}
+	return c
 }
 
-func NewMusicInfoClient(baseUrl string) *MusicInfoClient {
-	return &MusicInfoClient{
-		baseUrl:    baseUrl,
-		httpClient: &http.Client{},
+// Close releases idle upstream connections. It is meant to be called on shutdown.
+func (c *MusicInfoClient) Close() error {
+	base := c.baseTransport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if closer, ok := base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+	return nil
+}
+
+// Ping checks that the service is healthy: its health endpoint must respond
+// with a 2xx status.
+func (c *MusicInfoClient) Ping(ctx context.Context) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseUrl+c.healthPath, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return errors.Wrap(err, "music info service is unreachable")
+	}
+	response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return errors.Errorf("music info service responded with status %d", response.StatusCode)
 	}
+
+	return nil
 }
 
 func (c *MusicInfoClient) GetSongInfo(ctx context.Context, group, song string) (*model.SongDetail, error) {
+	if c.cache == nil {
+		songDetail, _, err := c.fetchSongInfo(ctx, group, song, nil)
+		return songDetail, err
+	}
+
+	key := cacheKey(group, song)
+	cached, err := c.cache.Get(ctx, key)
+	c.logCacheError(ctx, "failed to read music info cache", err)
+	if cached != nil && cached.Fresh(time.Now()) && !revalidating(ctx) {
+		if cached.NotFound {
+			return nil, ErrSongNotFound
+		}
+		return cached.SongDetail, nil
+	}
+
+	songDetail, entry, err := c.fetchSongInfo(ctx, group, song, cached)
+	if entry != nil {
+		c.logCacheError(ctx, "failed to write music info cache", c.cache.Set(ctx, key, *entry))
+	}
+
+	return songDetail, err
+}
+
+// logCacheError logs cache failures, which only cost an extra request.
+func (c *MusicInfoClient) logCacheError(ctx context.Context, msg string, err error) {
+	if err != nil {
+		c.logger.WarnContext(ctx, msg, "error", err)
+	}
+}
+
+// fetchSongInfo requests upstream, revalidating the stale entry if it has an
+// ETag. The returned entry is nil if the response must not be cached.
+func (c *MusicInfoClient) fetchSongInfo(ctx context.Context, group, song string, stale *CacheEntry) (*model.SongDetail, *CacheEntry, error) {
 	requestURL, err := url.Parse(fmt.Sprintf("%s/info", c.baseUrl))
 	if err != nil {
-		return nil, errors.Wrap(err, "url parse failed")
+		return nil, nil, errors.Wrap(err, "url parse failed")
 	}
 
 	query := requestURL.Query()
@@ -33,20 +136,53 @@ func (c *MusicInfoClient) GetSongInfo(ctx context.Context, group, song string) (
 	query.Set("song", song)
 	requestURL.RawQuery = query.Encode()
 
-	response, err := c.httpClient.Get(requestURL.String())
+	response, err := c.do(ctx, func() (*http.Request, error) {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		if stale != nil && stale.ETag != "" {
+			request.Header.Set("If-None-Match", stale.ETag)
+		}
+		return request, nil
+	})
 	if err != nil {
-		return nil, errors.Wrap(err, "http request failed")
+		return nil, nil, err
 	}
 	defer response.Body.Close()
 
-	if response.StatusCode != http.StatusOK {
-		return nil, errors.Errorf("request failed with status code %d", response.StatusCode)
+	cc := parseCacheControl(response.Header)
+	newEntry := func(entry CacheEntry, ttl time.Duration) *CacheEntry {
+		if cc.noStore {
+			return nil
+		}
+		entry.ExpiresAt = time.Now().Add(ttl)
+		return &entry
+	}
+
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		if stale == nil {
+			return nil, nil, errors.New("unexpected not modified response")
+		}
+		entry := newEntry(*stale, cc.ttl(c.cacheTTL))
+		if stale.NotFound {
+			return nil, entry, ErrSongNotFound
+		}
+		return stale.SongDetail, entry, nil
+	case http.StatusNotFound:
+		entry := newEntry(CacheEntry{NotFound: true, ETag: response.Header.Get("ETag")}, cc.ttl(c.negativeCacheTTL))
+		return nil, entry, ErrSongNotFound
+	default:
+		return nil, nil, errors.Errorf("request failed with status code %d", response.StatusCode)
 	}
 
 	var songDetail model.SongDetail
 	if err := json.NewDecoder(response.Body).Decode(&songDetail); err != nil {
-		return nil, fmt.Errorf("failed to decode JSON response: %w", err)
+		return nil, nil, fmt.Errorf("failed to decode JSON response: %w", err)
 	}
 
-	return &songDetail, nil
+	entry := newEntry(CacheEntry{SongDetail: &songDetail, ETag: response.Header.Get("ETag")}, cc.ttl(c.cacheTTL))
+	return &songDetail, entry, nil
 }
@@ -0,0 +1,65 @@
+package music_info_client
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+var _ Cache = (*LRUCache)(nil)
+
+// LRUCache is an in-memory cache bounded by the number of entries.
+// Expired entries are kept until evicted so that they can be revalidated.
+type LRUCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruItem struct {
+	key   string
+	entry CacheEntry
+}
+
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+func (c *LRUCache) Get(_ context.Context, key string) (*CacheEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, nil
+	}
+
+	c.order.MoveToFront(element)
+	entry := element.Value.(*lruItem).entry
+	return &entry, nil
+}
+
+func (c *LRUCache) Set(_ context.Context, key string, entry CacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*lruItem).entry = entry
+		c.order.MoveToFront(element)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&lruItem{key: key, entry: entry})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruItem).key)
+	}
+
+	return nil
+}
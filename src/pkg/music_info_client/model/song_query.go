@@ -0,0 +1,18 @@
+package model
+
+type SongQuery struct {
+	Group string `json:"group"`
+	Song  string `json:"song"`
+}
+
+// SongInfoResult is one item of a batch lookup response, in request order.
+type SongInfoResult struct {
+	Group      string      `json:"group"`
+	Song       string      `json:"song"`
+	Status     int         `json:"status"`
+	SongDetail *SongDetail `json:"songDetail,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	// ETag is the version of the item, the batch response has no header
+	// per item to send it in.
+	ETag string `json:"etag,omitempty"`
+}
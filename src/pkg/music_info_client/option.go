@@ -0,0 +1,138 @@
+package music_info_client
+
+import (
+	"golang.org/x/time/rate"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type Option func(*MusicInfoClient)
+
+// WithCache caches responses for ttl unless upstream sends Cache-Control.
+// Unknown songs are cached for negativeTTL.
+func WithCache(cache Cache, ttl, negativeTTL time.Duration) Option {
+	return func(c *MusicInfoClient) {
+		c.cache = cache
+		c.cacheTTL = ttl
+		c.negativeCacheTTL = negativeTTL
+	}
+}
+
+// WithBatchConcurrency limits the number of requests GetSongsInfo keeps in flight.
+func WithBatchConcurrency(concurrency int) Option {
+	return func(c *MusicInfoClient) {
+		c.batchConcurrency = max(concurrency, 1)
+	}
+}
+
+// MaxBatchSize is the largest batch the music info service accepts.
+const MaxBatchSize = 100
+
+// WithBatchEndpoint makes GetSongsInfo use the batch endpoint with up to
+// size songs per request instead of one request per song. The size is
+// clamped to MaxBatchSize.
+func WithBatchEndpoint(size int) Option {
+	return func(c *MusicInfoClient) {
+		c.batchSize = min(size, MaxBatchSize)
+	}
+}
+
+// WithRateLimit allows at most requestsPerSecond upstream requests on average
+// with bursts of up to burst requests. Zero rate disables the limit.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(c *MusicInfoClient) {
+		c.limiter = nil
+		if requestsPerSecond > 0 {
+			c.limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), max(burst, 1))
+		}
+	}
+}
+
+// WithMaxRetries sets how many times a request rejected with 429 is retried.
+func WithMaxRetries(retries int) Option {
+	return func(c *MusicInfoClient) {
+		c.maxRetries = max(retries, 0)
+	}
+}
+
+// WithTransport sends requests through the given round tripper instead of http.DefaultTransport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *MusicInfoClient) {
+		c.baseTransport = transport
+	}
+}
+
+// WithTimeout bounds every upstream round trip, including reading the
+// response body. Zero means no limit.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *MusicInfoClient) {
+		c.timeout = timeout
+	}
+}
+
+// WithTracing records a client span for every upstream round trip and sends
+// the trace context with the globally registered OpenTelemetry propagator.
+func WithTracing() Option {
+	return func(c *MusicInfoClient) {
+		c.tracing = true
+	}
+}
+
+// WithHealthPath sets the endpoint checked by Ping, /health by default.
+func WithHealthPath(path string) Option {
+	return func(c *MusicInfoClient) {
+		c.healthPath = path
+	}
+}
+
+// WithLogger sets the logger of the failures the client recovers from, such
+// as cache errors. slog.Default() is used otherwise.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *MusicInfoClient) {
+		c.logger = logger
+	}
+}
+
+func WithUserAgent(userAgent string) Option {
+	return WithHeader("User-Agent", userAgent)
+}
+
+func WithHeader(name, value string) Option {
+	return func(c *MusicInfoClient) {
+		c.headers[name] = value
+	}
+}
+
+// WithAPIKey sends the key in the given header, X-API-Key if empty.
+func WithAPIKey(header, key string) Option {
+	if header == "" {
+		header = "X-API-Key"
+	}
+	return WithHeader(header, key)
+}
+
+func WithBearerToken(token string) Option {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithHMACSigning signs every request with SignRequest.
+func WithHMACSigning(keyID, secret string) Option {
+	return func(c *MusicInfoClient) {
+		c.authenticators = append(c.authenticators, func(request *http.Request) error {
+			return SignRequest(request, keyID, secret, time.Now())
+		})
+	}
+}
+
+func WithRequestHook(hook RequestHook) Option {
+	return func(c *MusicInfoClient) {
+		c.requestHooks = append(c.requestHooks, hook)
+	}
+}
+
+func WithResponseHook(hook ResponseHook) Option {
+	return func(c *MusicInfoClient) {
+		c.responseHooks = append(c.responseHooks, hook)
+	}
+}
@@ -0,0 +1,102 @@
+package music_info_client
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"song-library-api/src/pkg/music_info_client/model"
+	"time"
+)
+
+var _ Cache = (*PostgresCache)(nil)
+
+// PostgresCache persists entries in a table so that they survive restarts
+// and are shared between instances.
+type PostgresCache struct {
+	pool  *pgxpool.Pool
+	table string
+}
+
+type postgresCacheRow struct {
+	SongDetail *model.SongDetail `db:"song_detail"`
+	NotFound   bool              `db:"not_found"`
+	ETag       string            `db:"etag"`
+	ExpiresAt  time.Time         `db:"expires_at"`
+}
+
+func NewPostgresCache(pool *pgxpool.Pool, table string) *PostgresCache {
+	return &PostgresCache{
+		pool:  pool,
+		table: table,
+	}
+}
+
+func (c *PostgresCache) Get(ctx context.Context, key string) (*CacheEntry, error) {
+	query := goqu.Dialect("postgres").
+		From(c.table).
+		Select("song_detail", "not_found", "etag", "expires_at").
+		Where(goqu.Ex{"key": key})
+
+	sql, args, err := query.ToSQL()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build query")
+	}
+
+	var row postgresCacheRow
+	if err = pgxscan.Get(ctx, c.pool, &row, sql, args...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to execute query")
+	}
+
+	return &CacheEntry{
+		SongDetail: row.SongDetail,
+		NotFound:   row.NotFound,
+		ETag:       row.ETag,
+		ExpiresAt:  row.ExpiresAt,
+	}, nil
+}
+
+func (c *PostgresCache) Set(ctx context.Context, key string, entry CacheEntry) error {
+	var songDetail any
+	if entry.SongDetail != nil {
+		data, err := json.Marshal(entry.SongDetail)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal song detail")
+		}
+		songDetail = string(data)
+	}
+
+	query := goqu.Dialect("postgres").
+		Insert(c.table).
+		Rows(goqu.Record{
+			"key":         key,
+			"song_detail": songDetail,
+			"not_found":   entry.NotFound,
+			"etag":        entry.ETag,
+			"expires_at":  entry.ExpiresAt,
+		}).
+		OnConflict(goqu.DoUpdate("key", goqu.Record{
+			"song_detail": goqu.L("EXCLUDED.song_detail"),
+			"not_found":   goqu.L("EXCLUDED.not_found"),
+			"etag":        goqu.L("EXCLUDED.etag"),
+			"expires_at":  goqu.L("EXCLUDED.expires_at"),
+			"updated_at":  goqu.L("CURRENT_TIMESTAMP"),
+		}))
+
+	sql, args, err := query.ToSQL()
+	if err != nil {
+		return errors.Wrap(err, "failed to build query")
+	}
+
+	if _, err = c.pool.Exec(ctx, sql, args...); err != nil {
+		return errors.Wrap(err, "failed to execute query")
+	}
+
+	return nil
+}
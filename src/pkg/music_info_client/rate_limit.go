@@ -0,0 +1,126 @@
+package music_info_client
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Stats are cumulative counters of the client's upstream traffic.
+type Stats struct {
+	Requests             uint64
+	ThrottledWaits       uint64
+	ThrottledWaitTime    time.Duration
+	RateLimitedResponses uint64
+	Retries              uint64
+}
+
+type stats struct {
+	requests             atomic.Uint64
+	throttledWaits       atomic.Uint64
+	throttledWaitTime    atomic.Int64
+	rateLimitedResponses atomic.Uint64
+	retries              atomic.Uint64
+}
+
+func (c *MusicInfoClient) Stats() Stats {
+	return Stats{
+		Requests:             c.stats.requests.Load(),
+		ThrottledWaits:       c.stats.throttledWaits.Load(),
+		ThrottledWaitTime:    time.Duration(c.stats.throttledWaitTime.Load()),
+		RateLimitedResponses: c.stats.rateLimitedResponses.Load(),
+		Retries:              c.stats.retries.Load(),
+	}
+}
+
+// do sends the request built by newRequest once a token is available and
+// retries it after the delay requested by 429 responses.
+func (c *MusicInfoClient) do(ctx context.Context, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := c.waitForToken(ctx); err != nil {
+			return nil, err
+		}
+
+		request, err := newRequest()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create request")
+		}
+
+		c.stats.requests.Add(1)
+		response, err := c.httpClient.Do(request)
+		if err != nil {
+			return nil, errors.Wrap(err, "http request failed")
+		}
+
+		if response.StatusCode != http.StatusTooManyRequests {
+			return response, nil
+		}
+
+		c.stats.rateLimitedResponses.Add(1)
+		delay := parseRetryAfter(response.Header.Get("Retry-After"), time.Now())
+		if attempt >= c.maxRetries {
+			return response, nil
+		}
+		response.Body.Close()
+
+		// Hold back every caller, not only this one, until upstream accepts requests again.
+		c.blockUntil(time.Now().Add(delay))
+		c.stats.retries.Add(1)
+	}
+}
+
+func (c *MusicInfoClient) waitForToken(ctx context.Context) error {
+	start := time.Now()
+
+	if blocked := time.Until(time.Unix(0, c.blockedUntil.Load())); blocked > 0 {
+		timer := time.NewTimer(blocked)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return errors.Wrap(err, "rate limiter wait failed")
+		}
+	}
+
+	// Waits shorter than a millisecond are scheduling noise rather than throttling.
+	if waited := time.Since(start); waited > time.Millisecond {
+		c.stats.throttledWaits.Add(1)
+		c.stats.throttledWaitTime.Add(int64(waited))
+	}
+
+	return nil
+}
+
+func (c *MusicInfoClient) blockUntil(until time.Time) {
+	for {
+		current := c.blockedUntil.Load()
+		if current >= until.UnixNano() || c.blockedUntil.CompareAndSwap(current, until.UnixNano()) {
+			return
+		}
+	}
+}
+
+// parseRetryAfter accepts both delay-seconds and HTTP-date values.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	const fallback = time.Second
+
+	if value == "" {
+		return fallback
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0)
+	}
+	return fallback
+}
@@ -0,0 +1,102 @@
+package music_info_client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", time.Second},
+		{"0", 0},
+		{"120", 2 * time.Minute},
+		{"-1", time.Second},
+		{"1.5", time.Second},
+		{"soon", time.Second},
+		{"Wed, 01 Jan 2025 12:00:30 GMT", 30 * time.Second},
+		{"Wednesday, 01-Jan-25 12:01:00 GMT", time.Minute},
+		{"Wed Jan  1 12:00:10 2025", 10 * time.Second},
+		{"Wed, 01 Jan 2025 11:59:00 GMT", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMusicInfoClient_do_retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxRetries   int
+		rejections   int
+		wantStatus   int
+		wantRequests uint64
+	}{
+		{name: "accepted", maxRetries: 2, rejections: 0, wantStatus: http.StatusOK, wantRequests: 1},
+		{name: "accepted after retries", maxRetries: 2, rejections: 2, wantStatus: http.StatusOK, wantRequests: 3},
+		{name: "out of retries", maxRetries: 1, rejections: 3, wantStatus: http.StatusTooManyRequests, wantRequests: 2},
+		{name: "no retries", maxRetries: 0, rejections: 1, wantStatus: http.StatusTooManyRequests, wantRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests <= tt.rejections {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			client := NewMusicInfoClient(server.URL, WithMaxRetries(tt.maxRetries))
+			response, err := client.do(context.Background(), func() (*http.Request, error) {
+				return http.NewRequest(http.MethodGet, server.URL, nil)
+			})
+			if err != nil {
+				t.Fatalf("do() error = %v", err)
+			}
+			response.Body.Close()
+
+			stats := client.Stats()
+			if response.StatusCode != tt.wantStatus || stats.Requests != tt.wantRequests {
+				t.Errorf("do() = %d after %d requests, want %d after %d",
+					response.StatusCode, stats.Requests, tt.wantStatus, tt.wantRequests)
+			}
+			if stats.Retries != tt.wantRequests-1 {
+				t.Errorf("Retries = %d, want %d", stats.Retries, tt.wantRequests-1)
+			}
+		})
+	}
+}
+
+func TestMusicInfoClient_do_blocksCallers(t *testing.T) {
+	client := NewMusicInfoClient("http://localhost")
+	client.blockUntil(time.Now().Add(time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := client.do(ctx, func() (*http.Request, error) {
+		t.Error("request sent while upstream is blocked")
+		return http.NewRequest(http.MethodGet, "http://localhost", nil)
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("do() error = %v, want context.DeadlineExceeded", err)
+	}
+}
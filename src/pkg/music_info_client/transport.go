@@ -0,0 +1,58 @@
+package music_info_client
+
+import (
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"net/http"
+	"time"
+)
+
+// RequestHook is called before a request is sent upstream.
+type RequestHook func(request *http.Request)
+
+// ResponseHook is called after every upstream round trip. Either response or err is nil.
+type ResponseHook func(request *http.Request, response *http.Response, err error, elapsed time.Duration)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+// transport decorates outgoing requests with headers, credentials and hooks.
+func (c *MusicInfoClient) transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if c.tracing {
+		base = otelhttp.NewTransport(base, otelhttp.WithSpanNameFormatter(
+			func(_ string, request *http.Request) string {
+				return "music-info " + request.Method + " " + request.URL.Path
+			}))
+	}
+
+	return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		request = request.Clone(request.Context())
+
+		for name, value := range c.headers {
+			request.Header.Set(name, value)
+		}
+		for _, authenticate := range c.authenticators {
+			if err := authenticate(request); err != nil {
+				return nil, err
+			}
+		}
+		for _, hook := range c.requestHooks {
+			hook(request)
+		}
+
+		start := time.Now()
+		response, err := base.RoundTrip(request)
+		elapsed := time.Since(start)
+
+		for _, hook := range c.responseHooks {
+			hook(request, response, err, elapsed)
+		}
+
+		return response, err
+	})
+}
@@ -0,0 +1,129 @@
+package partial_date
+
+import (
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+// Precision is the most specific known component of a date.
+type Precision string
+
+const (
+	PrecisionDay   Precision = "day"
+	PrecisionMonth Precision = "month"
+	PrecisionYear  Precision = "year"
+)
+
+var ErrInvalidDate = errors.New("invalid date")
+
+// Date is a calendar date that may be known only up to its month or year.
+// Unknown components of Time are set to their first value.
+type Date struct {
+	Time      time.Time
+	Precision Precision
+}
+
+type layout struct {
+	layout    string
+	precision Precision
+}
+
+// layouts are tried in order, so more specific layouts come first.
+var layouts = []layout{
+	{time.RFC3339, PrecisionDay},
+	{"2006-01-02", PrecisionDay},
+	{"02.01.2006", PrecisionDay},
+	{"2.1.2006", PrecisionDay},
+	{"2 January 2006", PrecisionDay},
+	{"January 2, 2006", PrecisionDay},
+	{"2 Jan 2006", PrecisionDay},
+	{"Jan 2, 2006", PrecisionDay},
+	{"2006-01", PrecisionMonth},
+	{"01.2006", PrecisionMonth},
+	{"January 2006", PrecisionMonth},
+	{"Jan 2006", PrecisionMonth},
+	{"2006", PrecisionYear},
+}
+
+// isoLayouts are the ISO 8601 calendar dates of reduced precision.
+var isoLayouts = []layout{
+	{"2006-01-02", PrecisionDay},
+	{"2006-01", PrecisionMonth},
+	{"2006", PrecisionYear},
+}
+
+// Parse accepts ISO 8601 dates, DD.MM.YYYY, year-month and year-only values.
+func Parse(value string) (Date, error) {
+	return parse(value, layouts)
+}
+
+// ParseISO accepts YYYY-MM-DD, YYYY-MM and YYYY only.
+func ParseISO(value string) (Date, error) {
+	return parse(value, isoLayouts)
+}
+
+func parse(value string, layouts []layout) (Date, error) {
+	value = strings.TrimSpace(value)
+	for _, l := range layouts {
+		t, err := time.Parse(l.layout, value)
+		if err != nil {
+			continue
+		}
+
+		y, m, d := t.Date()
+		return Date{
+			Time:      time.Date(y, m, d, 0, 0, 0, 0, time.UTC),
+			Precision: l.precision,
+		}, nil
+	}
+
+	return Date{}, errors.Wrapf(ErrInvalidDate, "unsupported date format %q", value)
+}
+
+func New(t time.Time, precision Precision) Date {
+	if precision == "" {
+		precision = PrecisionDay
+	}
+	return Date{Time: t, Precision: precision}
+}
+
+func (d Date) IsZero() bool {
+	return d.Time.IsZero()
+}
+
+// End returns the first day after the period the date covers.
+func (d Date) End() time.Time {
+	switch d.Precision {
+	case PrecisionYear:
+		return d.Time.AddDate(1, 0, 0)
+	case PrecisionMonth:
+		return d.Time.AddDate(0, 1, 0)
+	default:
+		return d.Time.AddDate(0, 0, 1)
+	}
+}
+
+// String formats the date as DD.MM.YYYY, MM.YYYY or YYYY.
+func (d Date) String() string {
+	switch d.Precision {
+	case PrecisionYear:
+		return d.Time.Format("2006")
+	case PrecisionMonth:
+		return d.Time.Format("01.2006")
+	default:
+		return d.Time.Format("02.01.2006")
+	}
+}
+
+// ISO formats the date as YYYY-MM-DD, YYYY-MM or YYYY.
+func (d Date) ISO() string {
+	switch d.Precision {
+	case PrecisionYear:
+		return d.Time.Format("2006")
+	case PrecisionMonth:
+		return d.Time.Format("2006-01")
+	default:
+		return d.Time.Format("2006-01-02")
+	}
+}
@@ -0,0 +1,117 @@
+package partial_date
+
+import (
+	"github.com/pkg/errors"
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Date
+	}{
+		{"2006-07-16", Date{date(2006, time.July, 16), PrecisionDay}},
+		{"2006-07-16T10:00:00+03:00", Date{date(2006, time.July, 16), PrecisionDay}},
+		{"16.07.2006", Date{date(2006, time.July, 16), PrecisionDay}},
+		{"6.7.2006", Date{date(2006, time.July, 6), PrecisionDay}},
+		{"16 July 2006", Date{date(2006, time.July, 16), PrecisionDay}},
+		{"July 16, 2006", Date{date(2006, time.July, 16), PrecisionDay}},
+		{"16 Jul 2006", Date{date(2006, time.July, 16), PrecisionDay}},
+		{"2006-07", Date{date(2006, time.July, 1), PrecisionMonth}},
+		{"07.2006", Date{date(2006, time.July, 1), PrecisionMonth}},
+		{"July 2006", Date{date(2006, time.July, 1), PrecisionMonth}},
+		{" 2006 ", Date{date(2006, time.January, 1), PrecisionYear}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := Parse(tt.value)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !got.Time.Equal(tt.want.Time) || got.Precision != tt.want.Precision {
+				t.Errorf("Parse() = %v (%s), want %v (%s)", got.Time, got.Precision, tt.want.Time, tt.want.Precision)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, value := range []string{"", "yesterday", "2006-13", "32.01.2006", "2006/07/16"} {
+		t.Run(value, func(t *testing.T) {
+			if _, err := Parse(value); !errors.Is(err, ErrInvalidDate) {
+				t.Errorf("Parse() error = %v, want ErrInvalidDate", err)
+			}
+		})
+	}
+}
+
+func TestParseISO(t *testing.T) {
+	tests := []struct {
+		value     string
+		want      Date
+		wantError bool
+	}{
+		{value: "2006-07-16", want: Date{date(2006, time.July, 16), PrecisionDay}},
+		{value: "2006-07", want: Date{date(2006, time.July, 1), PrecisionMonth}},
+		{value: "2006", want: Date{date(2006, time.January, 1), PrecisionYear}},
+		{value: "16.07.2006", wantError: true},
+		{value: "July 2006", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseISO(tt.value)
+			if tt.wantError {
+				if !errors.Is(err, ErrInvalidDate) {
+					t.Errorf("ParseISO() error = %v, want ErrInvalidDate", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseISO() error = %v", err)
+			}
+			if !got.Time.Equal(tt.want.Time) || got.Precision != tt.want.Precision {
+				t.Errorf("ParseISO() = %v (%s), want %v (%s)", got.Time, got.Precision, tt.want.Time, tt.want.Precision)
+			}
+		})
+	}
+}
+
+func TestDateFormatting(t *testing.T) {
+	tests := []struct {
+		date       Date
+		wantString string
+		wantISO    string
+		wantEnd    time.Time
+	}{
+		{Date{date(2006, time.December, 31), PrecisionDay}, "31.12.2006", "2006-12-31", date(2007, time.January, 1)},
+		{Date{date(2006, time.December, 1), PrecisionMonth}, "12.2006", "2006-12", date(2007, time.January, 1)},
+		{Date{date(2006, time.January, 1), PrecisionYear}, "2006", "2006", date(2007, time.January, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.date.Precision), func(t *testing.T) {
+			if got := tt.date.String(); got != tt.wantString {
+				t.Errorf("String() = %q, want %q", got, tt.wantString)
+			}
+			if got := tt.date.ISO(); got != tt.wantISO {
+				t.Errorf("ISO() = %q, want %q", got, tt.wantISO)
+			}
+			if got := tt.date.End(); !got.Equal(tt.wantEnd) {
+				t.Errorf("End() = %v, want %v", got, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestNewDefaultsToDay(t *testing.T) {
+	if got := New(date(2006, time.July, 16), ""); got.Precision != PrecisionDay {
+		t.Errorf("New() precision = %q, want %q", got.Precision, PrecisionDay)
+	}
+}